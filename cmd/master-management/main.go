@@ -1,12 +1,19 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"master-management-api/cmd/config"
 	"master-management-api/internal/db"
+	"master-management-api/internal/handlers/export"
+	"master-management-api/internal/jobs"
 	"master-management-api/internal/models"
 	"master-management-api/internal/routes"
+	"master-management-api/internal/subscribers"
+	"master-management-api/internal/workers"
 	"master-management-api/pkg/ai"
+	"os"
+	"strconv"
 )
 
 func init() {
@@ -18,8 +25,24 @@ func init() {
 	}
 }
 
+// workerConcurrency is how many jobs a worker runs at once, 4 unless WORKER_CONCURRENCY says otherwise.
+func workerConcurrency() int {
+	if value, err := strconv.Atoi(os.Getenv("WORKER_CONCURRENCY")); err == nil && value > 0 {
+		return value
+	}
+	return 4
+}
+
 func main() {
+	mode := flag.String("mode", "all", "what to run: server (HTTP API), worker (background jobs) or all")
+	flag.Parse()
+
 	log.Println("Starting Migration...")
+	// Email reminders default to on, also for settings rows that existed before the
+	// email_reminders column was added. The other toggles are left as users set them.
+	backfillSettings := db.DB.Migrator().HasTable(&models.UserSettings{}) &&
+		!db.DB.Migrator().HasColumn(&models.UserSettings{}, "EmailReminders")
+
 	if err := db.DB.AutoMigrate(
 		&models.User{},
 		&models.Task{},
@@ -30,10 +53,80 @@ func main() {
 		&models.Member{},
 		&models.UserSettings{},
 		&models.TaskSession{},
+		&models.UserIdentity{},
+		&models.OIDCState{},
+		&models.RateLimitBucket{},
+		&models.AuthSession{},
+		&models.DataExport{},
+		&models.WorkspaceInvitation{},
+		&models.JoinRequest{},
+		&models.Comment{},
+		&models.Mention{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.Project{},
+		&models.Sprint{},
+		&models.SprintTask{},
+		&models.ShareLink{},
+		&models.GuestAccess{},
+		&models.Notification{},
+		&models.SentReminder{},
+		&models.Digest{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.DailyActivity{},
+		&models.Job{},
+		&models.FeedToken{},
 	); err != nil {
 		log.Fatal("Failed to migrate:", err)
 	}
+	if backfillSettings {
+		if err := db.DB.Exec("UPDATE user_settings SET email_reminders = true").Error; err != nil {
+			log.Fatal("Failed to backfill email reminder settings:", err)
+		}
+	}
+	// Time zones briefly lived in user settings. Keep them for users who had not set
+	// one on their profile, which is where the zone is read from now.
+	if db.DB.Migrator().HasColumn("user_settings", "timezone") {
+		if err := db.DB.Exec(`
+			UPDATE users SET time_zone = user_settings.timezone
+			FROM user_settings
+			WHERE user_settings.user_id = users.id AND user_settings.timezone <> ''
+				AND (users.time_zone IS NULL OR users.time_zone = '')`).Error; err != nil {
+			log.Fatal("Failed to move time zones to profiles:", err)
+		}
+		if err := db.DB.Migrator().DropColumn("user_settings", "timezone"); err != nil {
+			log.Fatal("Failed to drop user_settings.timezone:", err)
+		}
+	}
 	log.Println("Migration complete.")
 
-	routes.SetupRouter()
+	subscribers.Register()
+	if err := subscribers.BackfillAnalytics(); err != nil {
+		log.Printf("Failed to backfill analytics: %v", err)
+	}
+
+	if *mode == "server" || *mode == "all" {
+		subscribers.RegisterRealtime()
+	}
+
+	workers.Register()
+	if err := export.ResumeOrphanedExports(); err != nil {
+		log.Printf("Failed to resume exports: %v", err)
+	}
+
+	switch *mode {
+	case "server":
+		routes.SetupRouter()
+	case "worker":
+		go jobs.RunScheduler()
+		jobs.RunWorker(workerConcurrency())
+	case "all":
+		go jobs.RunScheduler()
+		go jobs.RunWorker(workerConcurrency())
+		routes.SetupRouter()
+	default:
+		log.Fatalf("Unknown mode %q, use server, worker or all", *mode)
+	}
 }
@@ -0,0 +1,290 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/utils"
+	"master-management-api/pkg/mailer"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+
+	// Digests go out once the user's local clock passes this hour.
+	sendHour = 7
+	// How many tasks each list of the digest shows.
+	listLimit = 10
+	// How far ahead the upcoming section looks.
+	upcomingDays = 7
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// calendarDate matches a local day against due dates, which are stored as UTC midnight.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// PeriodSoFar returns the running daily or weekly period that contains now, in the
+// user's time zone.
+func PeriodSoFar(period string, settings models.UserSettings, location *time.Location, now time.Time) (time.Time, time.Time) {
+	today := midnight(now.In(location))
+	if period == Daily {
+		return today, now
+	}
+
+	firstDay, ok := weekdays[strings.ToLower(settings.FirstDayOfWeek)]
+	if !ok {
+		firstDay = time.Sunday
+	}
+	back := (int(today.Weekday()) - int(firstDay) + 7) % 7
+	return today.AddDate(0, 0, -back), now
+}
+
+// relevantTasks limits a task query to the user's personal tasks and the workspace
+// tasks assigned to them in workspaces they still belong to.
+func relevantTasks(userId uint) *gorm.DB {
+	return db.DB.Model(&models.Task{}).Where(
+		"((tasks.workspace_id IS NULL AND tasks.user_id = ?) OR "+
+			"(tasks.assignees IS NOT NULL AND tasks.assignees::jsonb @> ?::jsonb AND tasks.workspace_id IN "+
+			"(SELECT workspace_id FROM members WHERE user_id = ? AND deleted_at IS NULL)))",
+		userId, fmt.Sprintf("[%d]", userId), userId,
+	)
+}
+
+// Build collects the summary of everything the user did between start and end.
+func Build(userId uint, start time.Time, end time.Time) (models.DigestSummary, error) {
+	summary := models.DigestSummary{
+		Completed:     []models.DigestTask{},
+		StreaksKept:   []models.DigestStreak{},
+		StreaksBroken: []models.DigestStreak{},
+		Upcoming:      []models.DigestTask{},
+		Goals:         []models.DigestGoal{},
+	}
+
+	completed := relevantTasks(userId).Where("completed_at >= ? AND completed_at < ?", start, end)
+	if err := completed.Session(&gorm.Session{}).Count(&summary.CompletedCount).Error; err != nil {
+		return summary, err
+	}
+	if err := completed.Session(&gorm.Session{}).
+		Select("id AS task_id, title, completed_at").
+		Order("completed_at DESC").
+		Limit(listLimit).
+		Scan(&summary.Completed).Error; err != nil {
+		return summary, err
+	}
+
+	if err := db.DB.Model(&models.TaskSession{}).
+		Where("user_id = ? AND start_time >= ? AND start_time < ?", userId, start, end).
+		Select("COALESCE(SUM(duration), 0)").
+		Scan(&summary.FocusSeconds).Error; err != nil {
+		return summary, err
+	}
+
+	// A streak is alive while the task was started on the last day of the period. One
+	// that was alive going into the period but not at its end was broken in it.
+	lastDay := end.AddDate(0, 0, -1)
+	if err := relevantTasks(userId).
+		Select("id AS task_id, title, streak").
+		Where("streak >= 2 AND last_started_at >= ?", lastDay).
+		Order("streak DESC").
+		Limit(listLimit).
+		Scan(&summary.StreaksKept).Error; err != nil {
+		return summary, err
+	}
+	if err := relevantTasks(userId).
+		Select("id AS task_id, title, streak").
+		Where("streak >= 2 AND last_started_at >= ? AND last_started_at < ?", start.AddDate(0, 0, -1), lastDay).
+		Order("streak DESC").
+		Limit(listLimit).
+		Scan(&summary.StreaksBroken).Error; err != nil {
+		return summary, err
+	}
+
+	if err := relevantTasks(userId).
+		Select("id AS task_id, title, due_date").
+		Where("status <> 'completed' AND due_date >= ? AND due_date < ?", calendarDate(end), calendarDate(end).AddDate(0, 0, upcomingDays)).
+		Order("due_date ASC").
+		Limit(listLimit).
+		Scan(&summary.Upcoming).Error; err != nil {
+		return summary, err
+	}
+
+	goals, err := goalDeltas(userId, start, end)
+	if err != nil {
+		return summary, err
+	}
+	summary.Goals = goals
+
+	return summary, nil
+}
+
+// goalDeltas compares each goal's progress at the start and end of the period using
+// the goal_progress snapshots written whenever its progress is recalculated.
+func goalDeltas(userId uint, start time.Time, end time.Time) ([]models.DigestGoal, error) {
+	type progressEntry struct {
+		TaskId uint
+		Title  string
+		Before string
+		After  string
+	}
+
+	var entries []progressEntry
+	if err := db.DB.Table("task_histories").
+		Select("task_histories.task_id, task_histories.before, task_histories.after, tasks.title").
+		Joins("JOIN tasks ON tasks.id = task_histories.task_id AND tasks.deleted_at IS NULL").
+		Where("task_histories.action = 'goal_progress' AND task_histories.deleted_at IS NULL").
+		Where("task_histories.created_at >= ? AND task_histories.created_at < ?", start, end).
+		Where("tasks.type = 'goal' AND tasks.id IN (?)", relevantTasks(userId).Select("tasks.id")).
+		Order("task_histories.id ASC").
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	byGoal := map[uint]*models.DigestGoal{}
+	order := []uint{}
+	for _, entry := range entries {
+		after, _ := strconv.ParseFloat(entry.After, 64)
+		goal, ok := byGoal[entry.TaskId]
+		if !ok {
+			before, _ := strconv.ParseFloat(entry.Before, 64)
+			goal = &models.DigestGoal{
+				TaskId: entry.TaskId,
+				Title:  entry.Title,
+				Before: before,
+			}
+			byGoal[entry.TaskId] = goal
+			order = append(order, entry.TaskId)
+		}
+		goal.After = after
+	}
+
+	goals := []models.DigestGoal{}
+	for _, id := range order {
+		if byGoal[id].After != byGoal[id].Before {
+			goals = append(goals, *byGoal[id])
+		}
+	}
+	sort.SliceStable(goals, func(i, j int) bool {
+		return goals[i].After-goals[i].Before > goals[j].After-goals[j].Before
+	})
+	if len(goals) > listLimit {
+		goals = goals[:listLimit]
+	}
+	return goals, nil
+}
+
+func empty(summary models.DigestSummary) bool {
+	return summary.CompletedCount == 0 && summary.FocusSeconds == 0 &&
+		len(summary.StreaksKept) == 0 && len(summary.StreaksBroken) == 0 &&
+		len(summary.Upcoming) == 0 && len(summary.Goals) == 0
+}
+
+// generate builds and stores one digest and emails it the first time it is stored.
+// Digests with nothing to report are kept for the in-app view but not emailed.
+func generate(user models.User, period string, start time.Time, end time.Time) error {
+	var count int64
+	db.DB.Model(&models.Digest{}).Where("user_id = ? AND period = ? AND period_start = ?", user.ID, period, start).Count(&count)
+	if count > 0 {
+		return nil
+	}
+
+	summary, err := Build(user.ID, start, end)
+	if err != nil {
+		return err
+	}
+	text, html, err := Render(period, start, end, summary)
+	if err != nil {
+		return err
+	}
+
+	digest := models.Digest{
+		UserId:      user.ID,
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Summary:     summary,
+		HTML:        html,
+		Text:        text,
+	}
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&digest)
+	if result.Error != nil || result.RowsAffected == 0 || empty(summary) {
+		return result.Error
+	}
+
+	if err := mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: Subject(period, start),
+		Text:    text,
+		HTML:    html,
+	}); err != nil {
+		return err
+	}
+	return db.DB.Model(&digest).Update("emailed_at", time.Now()).Error
+}
+
+// GenerateDue creates the digests whose period ended since the last run. Daily digests
+// cover the previous local day, weekly ones the previous week and are sent on the
+// user's first day of the week.
+func GenerateDue() error {
+	type subscriber struct {
+		models.UserSettings
+		Email    string
+		TimeZone *string
+	}
+
+	var subscribers []subscriber
+	if err := db.DB.Table("user_settings").
+		Select("user_settings.*, users.email, users.time_zone").
+		Joins("JOIN users ON users.id = user_settings.user_id AND users.deleted_at IS NULL").
+		Where("user_settings.deleted_at IS NULL AND (user_settings.daily_summary OR user_settings.weekly_summary)").
+		Scan(&subscribers).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, s := range subscribers {
+		location := utils.Location(s.TimeZone)
+		local := now.In(location)
+		if local.Hour() < sendHour {
+			continue
+		}
+		user := models.User{ID: s.UserId, Email: s.Email}
+		today := midnight(local)
+
+		if s.DailySummary {
+			if err := generate(user, Daily, today.AddDate(0, 0, -1), today); err != nil {
+				log.Printf("Failed to generate daily digest for user %d: %v", s.UserId, err)
+			}
+		}
+		if weekStart, _ := PeriodSoFar(Weekly, s.UserSettings, location, now); s.WeeklySummary && weekStart.Equal(today) {
+			if err := generate(user, Weekly, today.AddDate(0, 0, -7), today); err != nil {
+				log.Printf("Failed to generate weekly digest for user %d: %v", s.UserId, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"master-management-api/internal/models"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+type view struct {
+	Heading string
+	Range   string
+	models.DigestSummary
+}
+
+var funcs = map[string]any{
+	"focus": func(seconds int64) string {
+		hours, minutes := seconds/3600, seconds%3600/60
+		if hours == 0 {
+			return fmt.Sprintf("%dm", minutes)
+		}
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	},
+	"date": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format("Mon, Jan 2")
+	},
+	"progress": func(goal models.DigestGoal) string {
+		format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) + "%" }
+		return format(goal.Before) + " → " + format(goal.After)
+	},
+}
+
+const textLayout = `{{.Heading}}
+{{.Range}}
+
+Completed: {{.CompletedCount}} task(s)
+{{- range .Completed}}
+  - {{.Title}}
+{{- end}}
+
+Focus time: {{focus .FocusSeconds}}
+{{- if .StreaksKept}}
+
+Streaks kept:
+{{- range .StreaksKept}}
+  - {{.Title}} ({{.Streak}} days)
+{{- end}}
+{{- end}}
+{{- if .StreaksBroken}}
+
+Streaks broken:
+{{- range .StreaksBroken}}
+  - {{.Title}} (was {{.Streak}} days)
+{{- end}}
+{{- end}}
+{{- if .Goals}}
+
+Goal progress:
+{{- range .Goals}}
+  - {{.Title}}: {{progress .}}
+{{- end}}
+{{- end}}
+{{- if .Upcoming}}
+
+Coming up:
+{{- range .Upcoming}}
+  - {{.Title}}, due {{date .DueDate}}
+{{- end}}
+{{- end}}
+
+You receive this summary because it is turned on in your notification settings.
+`
+
+const htmlLayout = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #1f2937; max-width: 560px; margin: 0 auto;">
+<h2 style="margin-bottom: 4px;">{{.Heading}}</h2>
+<p style="color: #6b7280; margin-top: 0;">{{.Range}}</p>
+<table style="width: 100%; margin: 16px 0;">
+<tr>
+<td><strong style="font-size: 24px;">{{.CompletedCount}}</strong><br>tasks completed</td>
+<td><strong style="font-size: 24px;">{{focus .FocusSeconds}}</strong><br>focus time</td>
+</tr>
+</table>
+{{- if .Completed}}
+<h3>Completed</h3>
+<ul>{{range .Completed}}<li>{{.Title}}</li>{{end}}</ul>
+{{- end}}
+{{- if .StreaksKept}}
+<h3>Streaks kept</h3>
+<ul>{{range .StreaksKept}}<li>{{.Title}} ({{.Streak}} days)</li>{{end}}</ul>
+{{- end}}
+{{- if .StreaksBroken}}
+<h3>Streaks broken</h3>
+<ul>{{range .StreaksBroken}}<li>{{.Title}} (was {{.Streak}} days)</li>{{end}}</ul>
+{{- end}}
+{{- if .Goals}}
+<h3>Goal progress</h3>
+<ul>{{range .Goals}}<li>{{.Title}}: {{progress .}}</li>{{end}}</ul>
+{{- end}}
+{{- if .Upcoming}}
+<h3>Coming up</h3>
+<ul>{{range .Upcoming}}<li>{{.Title}}, due {{date .DueDate}}</li>{{end}}</ul>
+{{- end}}
+<p style="color: #9ca3af; font-size: 12px;">You receive this summary because it is turned on in your notification settings.</p>
+</body>
+</html>
+`
+
+var (
+	textTemplate = template.Must(template.New("digest").Funcs(funcs).Parse(textLayout))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(funcs).Parse(htmlLayout))
+)
+
+// Subject is the email subject of a digest.
+func Subject(period string, start time.Time) string {
+	if period == Weekly {
+		return "Your week starting " + start.Format("Jan 2")
+	}
+	return "Your day: " + start.Format("Monday, Jan 2")
+}
+
+// Render produces the plain-text and HTML versions of a digest.
+func Render(period string, start time.Time, end time.Time, summary models.DigestSummary) (string, string, error) {
+	v := view{
+		Heading:       Subject(period, start),
+		Range:         start.Format("Jan 2, 2006"),
+		DigestSummary: summary,
+	}
+	if last := end.Add(-time.Second); last.YearDay() != start.YearDay() || last.Year() != start.Year() {
+		v.Range += " – " + last.Format("Jan 2, 2006")
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, v); err != nil {
+		return "", "", err
+	}
+	if err := htmlTemplate.Execute(&html, v); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
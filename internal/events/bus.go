@@ -0,0 +1,238 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxAttempts = 10
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+	claimLease  = 2 * time.Minute // how long a claimed event is hidden from other dispatchers
+	batchSize   = 50
+	// Events are normally handled by the request that recorded them. The dispatcher
+	// only picks them up once this grace period passed, e.g. after a crash.
+	flushGrace = 30 * time.Second
+	// Processed events are kept this long for debugging before they are removed.
+	retention = 7 * 24 * time.Hour
+)
+
+type subscriber struct {
+	name   string
+	handle func(payload []byte) error
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[string][]subscriber{}
+)
+
+// Subscribe registers fn for every event of type T. The name identifies the subscriber
+// in the outbox, so when one subscriber fails only that one is retried. Subscribers are
+// registered once at startup, before any event is processed.
+func Subscribe[T Event](name string, fn func(T) error) {
+	var zero T
+	event := zero.EventName()
+
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers[event] = append(subscribers[event], subscriber{
+		name: name,
+		handle: func(payload []byte) error {
+			var e T
+			if err := json.Unmarshal(payload, &e); err != nil {
+				return err
+			}
+			return fn(e)
+		},
+	})
+}
+
+// Record writes the event to the outbox as part of tx, so it is stored if and only if
+// the change that caused it is.
+func Record(tx *gorm.DB, event Event) (uint, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	row := models.OutboxEvent{
+		Name:        event.EventName(),
+		Payload:     string(payload),
+		Status:      "pending",
+		AvailableAt: time.Now().Add(flushGrace),
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return 0, err
+	}
+	return row.ID, nil
+}
+
+// Batch collects the events recorded in a transaction so they can be handled as soon
+// as it commits.
+type Batch struct {
+	ids []uint
+}
+
+func (b *Batch) Record(tx *gorm.DB, event Event) error {
+	id, err := Record(tx, event)
+	if err != nil {
+		return err
+	}
+	b.ids = append(b.ids, id)
+	return nil
+}
+
+// Flush hands the recorded events to their subscribers right away, so the caller sees
+// their effects. It must only be called after the transaction committed. Anything that
+// fails is retried by the dispatcher.
+func (b *Batch) Flush() {
+	Process(b.ids...)
+	b.ids = nil
+}
+
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// claim picks pending events, counts the attempt and hides them behind a lease so
+// other dispatchers skip them while they are being handled.
+func claim(scope func(*gorm.DB) *gorm.DB) ([]models.OutboxEvent, error) {
+	var rows []models.OutboxEvent
+	lease := time.Now().Add(claimLease)
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})).
+			Where("status = 'pending'").
+			Order("id ASC").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(rows))
+		for i := range rows {
+			ids = append(ids, rows[i].ID)
+			rows[i].Attempts++
+			rows[i].AvailableAt = lease
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"available_at": lease,
+		}).Error
+	})
+	return rows, err
+}
+
+func run(sub subscriber, payload string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handle([]byte(payload))
+}
+
+// handle runs every subscriber that has not handled the event yet and records the outcome.
+func handle(row models.OutboxEvent) {
+	mu.RLock()
+	subs := subscribers[row.Name]
+	mu.RUnlock()
+
+	completed := []string{}
+	if row.Completed != nil {
+		completed = *row.Completed
+	}
+
+	var failures []string
+	for _, sub := range subs {
+		if slices.Contains(completed, sub.name) {
+			continue
+		}
+		if err := run(sub, row.Payload); err != nil {
+			failures = append(failures, sub.name+": "+err.Error())
+			continue
+		}
+		completed = append(completed, sub.name)
+	}
+
+	now := time.Now()
+	row.Completed = &completed
+	if len(failures) == 0 {
+		row.Status = "processed"
+		row.ProcessedAt = &now
+		row.Error = ""
+	} else {
+		row.Error = strings.Join(failures, "; ")
+		if row.Attempts >= maxAttempts {
+			row.Status = "failed"
+			log.Printf("Giving up on event %d (%s): %s", row.ID, row.Name, row.Error)
+		} else {
+			row.AvailableAt = now.Add(backoff(row.Attempts))
+		}
+	}
+
+	if err := db.DB.Save(&row).Error; err != nil {
+		log.Printf("Failed to record outcome of event %d: %v", row.ID, err)
+	}
+}
+
+// Process handles the given events if nobody has picked them up yet.
+func Process(ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+
+	// Only first attempts, so an event the dispatcher already claimed is left alone
+	rows, err := claim(func(query *gorm.DB) *gorm.DB {
+		return query.Where("id IN ? AND attempts = 0", ids)
+	})
+	if err != nil {
+		log.Printf("Failed to claim events: %v", err)
+		return
+	}
+	for _, row := range rows {
+		handle(row)
+	}
+}
+
+// DispatchDue handles every event that was not processed by its request or is due to
+// be retried, then removes old processed events.
+func DispatchDue() error {
+	for {
+		rows, err := claim(func(query *gorm.DB) *gorm.DB {
+			return query.Where("available_at <= ?", time.Now())
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			handle(row)
+		}
+		if len(rows) < batchSize {
+			break
+		}
+	}
+
+	return db.DB.Unscoped().
+		Where("status = 'processed' AND processed_at < ?", time.Now().Add(-retention)).
+		Delete(&models.OutboxEvent{}).Error
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"master-management-api/internal/models"
+	"time"
+)
+
+// Event is a domain event. Its name is what the outbox stores and what subscribers
+// are registered under, so it must stay stable once events have been recorded.
+type Event interface {
+	EventName() string
+}
+
+type TaskCreated struct {
+	Task    models.Task `json:"task"`
+	ActorId uint        `json:"actor_id"`
+}
+
+// TaskUpdated carries the task before and after the change so subscribers can tell
+// what actually changed.
+type TaskUpdated struct {
+	Before  models.Task `json:"before"`
+	After   models.Task `json:"after"`
+	ActorId uint        `json:"actor_id"`
+}
+
+type TaskDeleted struct {
+	Task    models.Task `json:"task"`
+	ActorId uint        `json:"actor_id"`
+}
+
+type ChecklistCreated struct {
+	Checklist models.Checklist `json:"checklist"`
+	ActorId   uint             `json:"actor_id"`
+}
+
+// ChecklistUpdated is a change to an item's title. Checking or unchecking it is
+// ChecklistToggled, since that is what moves progress.
+type ChecklistUpdated struct {
+	Checklist models.Checklist `json:"checklist"`
+	ActorId   uint             `json:"actor_id"`
+}
+
+type ChecklistToggled struct {
+	Checklist models.Checklist `json:"checklist"`
+	ActorId   uint             `json:"actor_id"`
+}
+
+type ChecklistDeleted struct {
+	Checklist models.Checklist `json:"checklist"`
+	ActorId   uint             `json:"actor_id"`
+}
+
+type NoteCreated struct {
+	Note    models.Note `json:"note"`
+	ActorId uint        `json:"actor_id"`
+}
+
+type NoteUpdated struct {
+	Note    models.Note `json:"note"`
+	ActorId uint        `json:"actor_id"`
+}
+
+type NoteDeleted struct {
+	Note    models.Note `json:"note"`
+	ActorId uint        `json:"actor_id"`
+}
+
+type SessionStarted struct {
+	UserId uint `json:"user_id"`
+	TaskId uint `json:"task_id"`
+}
+
+type SessionStopped struct {
+	SessionId uint      `json:"session_id"`
+	UserId    uint      `json:"user_id"`
+	TaskId    uint      `json:"task_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Duration  int64     `json:"duration"`
+}
+
+type MemberJoined struct {
+	Member  models.Member `json:"member"`
+	ActorId uint          `json:"actor_id"`
+}
+
+type MemberUpdated struct {
+	Member  models.Member `json:"member"`
+	ActorId uint          `json:"actor_id"`
+}
+
+type MemberRemoved struct {
+	Member  models.Member `json:"member"`
+	ActorId uint          `json:"actor_id"`
+}
+
+// GoalProgressed is recorded whenever a task's progress is recalculated to a new value.
+type GoalProgressed struct {
+	Task    models.Task `json:"task"`
+	Before  float64     `json:"before"`
+	After   float64     `json:"after"`
+	ActorId uint        `json:"actor_id"`
+}
+
+func (TaskCreated) EventName() string      { return "task.created" }
+func (TaskUpdated) EventName() string      { return "task.updated" }
+func (TaskDeleted) EventName() string      { return "task.deleted" }
+func (ChecklistCreated) EventName() string { return "checklist.created" }
+func (ChecklistUpdated) EventName() string { return "checklist.updated" }
+func (ChecklistToggled) EventName() string { return "checklist.toggled" }
+func (ChecklistDeleted) EventName() string { return "checklist.deleted" }
+func (NoteCreated) EventName() string      { return "note.created" }
+func (NoteUpdated) EventName() string      { return "note.updated" }
+func (NoteDeleted) EventName() string      { return "note.deleted" }
+func (SessionStarted) EventName() string   { return "session.started" }
+func (SessionStopped) EventName() string   { return "session.stopped" }
+func (MemberJoined) EventName() string     { return "member.joined" }
+func (MemberUpdated) EventName() string    { return "member.updated" }
+func (MemberRemoved) EventName() string    { return "member.removed" }
+func (GoalProgressed) EventName() string   { return "goal.progressed" }
@@ -0,0 +1,249 @@
+package activity
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Summary string     `xml:"summary,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// feedURL builds the link handed to feed readers.
+func feedURL(workspaceId uint, token string) string {
+	return fmt.Sprintf("/feeds/workspaces/%d/activity.atom?token=%s", workspaceId, url.QueryEscape(token))
+}
+
+// issueFeedToken replaces the user's feed token for the workspace, or creates one.
+func issueFeedToken(workspaceId uint, userId uint) (string, error) {
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	feedToken := models.FeedToken{UserId: userId, WorkspaceId: workspaceId, Token: token}
+	err = db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "workspace_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"token": token, "updated_at": time.Now(), "last_used_at": nil}),
+	}).Create(&feedToken).Error
+	return token, err
+}
+
+// describe turns a feed item into a one line, human readable title.
+func describe(item Item) string {
+	actor := strings.TrimSpace(item.ActorName)
+	if actor == "" {
+		actor = "Someone"
+	}
+	task := ""
+	if item.TaskTitle != nil {
+		task = fmt.Sprintf("%q", *item.TaskTitle)
+	}
+
+	switch item.Action {
+	case "created":
+		return fmt.Sprintf("%s created %s", actor, task)
+	case "status_update":
+		return fmt.Sprintf("%s moved %s from %s to %s", actor, task, item.Before, item.After)
+	case "title_update":
+		return fmt.Sprintf("%s renamed %q to %q", actor, item.Before, item.After)
+	case "description_update":
+		return fmt.Sprintf("%s updated the description of %s", actor, task)
+	case "priority_change":
+		return fmt.Sprintf("%s changed the priority of %s to %s", actor, task, item.After)
+	case "progress_update":
+		return fmt.Sprintf("%s updated progress on %s to %s", actor, task, item.After)
+	case "goal_progress":
+		return fmt.Sprintf("%s moved %s from %s%% to %s%%", actor, task, item.Before, item.After)
+	case "assignee_added":
+		return fmt.Sprintf("%s assigned someone to %s", actor, task)
+	case "assignee_removed":
+		return fmt.Sprintf("%s unassigned someone from %s", actor, task)
+	case "comment":
+		return fmt.Sprintf("%s commented on %s", actor, task)
+	case "comment_edited":
+		return fmt.Sprintf("%s edited a comment on %s", actor, task)
+	case "comment_deleted":
+		return fmt.Sprintf("%s deleted a comment on %s", actor, task)
+	case "subtask":
+		return fmt.Sprintf("%s added subtask %q to %s", actor, item.After, task)
+	case "started":
+		return fmt.Sprintf("%s started working on %s", actor, task)
+	case "stopped":
+		return fmt.Sprintf("%s stopped working on %s", actor, task)
+	case "member_joined":
+		return fmt.Sprintf("%s joined the workspace", actor)
+	case "member_left":
+		return fmt.Sprintf("%s left the workspace", actor)
+	default:
+		if task != "" {
+			return fmt.Sprintf("%s updated %s", actor, task)
+		}
+		return fmt.Sprintf("%s made a change", actor)
+	}
+}
+
+// GetFeedURL hands out a private Atom feed link for feed readers, which cannot send
+// the auth cookie or header. The same link is returned until it is rotated or revoked.
+func GetFeedURL(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	workspaceId := memberData.(models.Member).WorkspaceId
+
+	var feedToken models.FeedToken
+	err := db.DB.Where("user_id = ? AND workspace_id = ?", userId, workspaceId).First(&feedToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		feedToken.Token, err = issueFeedToken(workspaceId, userId)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feed link!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": feedURL(workspaceId, feedToken.Token)})
+}
+
+// RotateFeedURL replaces the feed link, so the old one stops working.
+func RotateFeedURL(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	workspaceId := memberData.(models.Member).WorkspaceId
+
+	token, err := issueFeedToken(workspaceId, userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate feed link!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": feedURL(workspaceId, token)})
+}
+
+// RevokeFeedURL turns the feed link off until a new one is requested.
+func RevokeFeedURL(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	workspaceId := memberData.(models.Member).WorkspaceId
+
+	if err := db.DB.Unscoped().Where("user_id = ? AND workspace_id = ?", userId, workspaceId).Delete(&models.FeedToken{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke feed link!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feed link revoked."})
+}
+
+// GetAtomFeed serves the feed to holders of a feed link, so it sits outside
+// RequireAuth. Membership is checked on every request so leaving the workspace
+// cuts the feed off.
+func GetAtomFeed(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	var feedToken models.FeedToken
+	token := c.Query("token")
+	if token == "" || db.DB.Where("token = ? AND workspace_id = ?", token, workspaceId).First(&feedToken).Error != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid feed link"})
+		return
+	}
+	userId := feedToken.UserId
+	db.DB.Model(&feedToken).UpdateColumn("last_used_at", time.Now())
+
+	member, err := permissions.GetMember(workspaceId, userId)
+	if err != nil || !permissions.Can(member.Role, permissions.ViewAllTasks) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this workspace"})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	f, problem := parseFilter(c)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	items, _, err := load(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve activity!"})
+		return
+	}
+
+	updated := workspace.UpdatedAt
+	if len(items) > 0 {
+		updated = items[0].CreatedAt
+	}
+
+	feedId := fmt.Sprintf("urn:master-management:workspace:%d:activity", workspace.ID)
+	feed := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		ID:      feedId,
+		Title:   workspace.Name + " activity",
+		Updated: updated.UTC().Format(time.RFC3339),
+		Link:    []atomLink{{Href: c.Request.URL.RequestURI(), Rel: "self"}},
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			ID:      feedId + ":" + item.Key,
+			Title:   describe(item),
+			Updated: item.CreatedAt.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: item.ActorName},
+		}
+		if item.Kind == "comment" {
+			entry.Summary = item.After
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build feed!"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), output...))
+}
@@ -0,0 +1,228 @@
+package activity
+
+import (
+	"encoding/base64"
+	"master-management-api/internal/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 30
+	maxPageSize     = 100
+)
+
+// Item is a single entry of the workspace activity feed.
+type Item struct {
+	Key       string    `json:"key"`
+	Kind      string    `json:"kind"` // "history" | "progress" | "comment" | "membership"
+	Action    string    `json:"action"`
+	ActorId   uint      `json:"actor_id"`
+	ActorName string    `json:"actor_name"`
+	TaskId    *uint     `json:"task_id"`
+	TaskTitle *string   `json:"task_title"`
+	TaskType  *string   `json:"task_type"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type filter struct {
+	workspaceId string
+	memberId    string
+	actions     []string
+	start       *time.Time
+	end         *time.Time
+	cursorTime  *time.Time
+	cursorKey   string
+	limit       int
+}
+
+// The feed is built from every source at query time, so nothing has to be kept in
+// sync. Comments come from the comments table, which is why history rows for
+// new comments are skipped. Progress entries are the goal_progress snapshots written
+// for every recalculation, so checklist and subtask changes show up too.
+const feedQuery = `
+	SELECT feed.*, COALESCE(CONCAT(u.first_name, ' ', u.last_name), '') AS actor_name
+	FROM (
+		SELECT
+			'h:' || h.id AS key,
+			CASE WHEN h.action = 'goal_progress' THEN 'progress' ELSE 'history' END AS kind,
+			h.action,
+			h.user_id AS actor_id,
+			t.id AS task_id,
+			t.title AS task_title,
+			t.type AS task_type,
+			h.before,
+			h.after,
+			h.created_at
+		FROM task_histories h
+		JOIN tasks t ON t.id = h.task_id
+		WHERE t.workspace_id = @workspace AND t.deleted_at IS NULL AND h.deleted_at IS NULL AND h.action <> 'comment'
+
+		UNION ALL
+
+		SELECT 'c:' || c.id, 'comment', 'comment', c.user_id, t.id, t.title, t.type, '', c.content, c.created_at
+		FROM comments c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE t.workspace_id = @workspace AND t.deleted_at IS NULL AND c.deleted_at IS NULL
+
+		UNION ALL
+
+		SELECT 'j:' || m.id, 'membership', 'member_joined', m.user_id, NULL, NULL, NULL, '', m.role, COALESCE(m.joined_at, m.created_at)
+		FROM members m
+		WHERE m.workspace_id = @workspace
+
+		UNION ALL
+
+		SELECT 'l:' || m.id, 'membership', 'member_left', m.user_id, NULL, NULL, NULL, m.role, '', m.deleted_at
+		FROM members m
+		WHERE m.workspace_id = @workspace AND m.deleted_at IS NOT NULL
+	) feed
+	LEFT JOIN users u ON u.id = feed.actor_id
+`
+
+// load returns one page of the feed, newest first, and the cursor of the next page.
+func load(f filter) ([]Item, string, error) {
+	conditions := []string{}
+	params := map[string]interface{}{"workspace": f.workspaceId}
+
+	if f.memberId != "" {
+		conditions = append(conditions, "feed.actor_id = @member")
+		params["member"] = f.memberId
+	}
+	if len(f.actions) > 0 {
+		conditions = append(conditions, "(feed.action IN @actions OR feed.kind IN @actions)")
+		params["actions"] = f.actions
+	}
+	if f.start != nil {
+		conditions = append(conditions, "feed.created_at >= @start")
+		params["start"] = *f.start
+	}
+	if f.end != nil {
+		conditions = append(conditions, "feed.created_at < @end")
+		params["end"] = *f.end
+	}
+	if f.cursorTime != nil {
+		conditions = append(conditions, "(feed.created_at, feed.key) < (@cursorTime, @cursorKey)")
+		params["cursorTime"] = *f.cursorTime
+		params["cursorKey"] = f.cursorKey
+	}
+
+	query := feedQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY feed.created_at DESC, feed.key DESC LIMIT " + strconv.Itoa(f.limit+1)
+
+	items := []Item{}
+	if err := db.DB.Raw(query, params).Scan(&items).Error; err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(items) > f.limit {
+		items = items[:f.limit]
+		last := items[len(items)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.Key)
+	}
+
+	return items, nextCursor, nil
+}
+
+func encodeCursor(createdAt time.Time, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + key))
+}
+
+func decodeCursor(cursor string) (time.Time, string, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", false
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return createdAt, parts[1], true
+}
+
+// parseFilter reads member, action, startDate / endDate (DD-MM-YYYY), cursor and limit.
+func parseFilter(c *gin.Context) (filter, string) {
+	f := filter{
+		workspaceId: c.Param("workspaceId"),
+		memberId:    c.Query("member"),
+		actions:     c.QueryArray("action"),
+		limit:       defaultPageSize,
+	}
+
+	if f.memberId != "" {
+		if _, err := strconv.ParseUint(f.memberId, 10, 64); err != nil {
+			return f, "Invalid member"
+		}
+	}
+
+	if value := c.Query("startDate"); value != "" {
+		start, err := time.ParseInLocation("02-01-2006", value, time.Local)
+		if err != nil {
+			return f, "Invalid date format"
+		}
+		f.start = &start
+	}
+	if value := c.Query("endDate"); value != "" {
+		end, err := time.ParseInLocation("02-01-2006", value, time.Local)
+		if err != nil {
+			return f, "Invalid date format"
+		}
+		end = end.AddDate(0, 0, 1)
+		f.end = &end
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return f, "Invalid limit"
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		f.limit = limit
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		createdAt, key, ok := decodeCursor(cursor)
+		if !ok {
+			return f, "Invalid cursor"
+		}
+		f.cursorTime = &createdAt
+		f.cursorKey = key
+	}
+
+	return f, ""
+}
+
+func GetActivity(c *gin.Context) {
+	f, problem := parseFilter(c)
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	items, nextCursor, err := load(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve activity!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        items,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func findJob(c *gin.Context) (models.Job, bool) {
+	var job models.Job
+	if err := db.DB.First(&job, c.Param("jobId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return job, false
+	}
+	return job, true
+}
+
+// GetJobs lists jobs newest first, filtered by ?status= and ?kind=. Older pages are
+// fetched with ?before=<id of the last job>.
+func GetJobs(c *gin.Context) {
+	limit := defaultPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageSize)
+	}
+
+	query := db.DB.Model(&models.Job{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if before := c.Query("before"); before != "" {
+		if _, err := strconv.ParseUint(before, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	jobs := []models.Job{}
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve jobs!"})
+		return
+	}
+
+	var nextCursor *uint
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		nextCursor = &jobs[limit-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        jobs,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetJobStats counts jobs per kind and status.
+func GetJobStats(c *gin.Context) {
+	type row struct {
+		Kind   string `json:"kind"`
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}
+
+	stats := []row{}
+	if err := db.DB.Model(&models.Job{}).
+		Select("kind, status, COUNT(*) AS count").
+		Group("kind, status").
+		Order("kind, status").
+		Scan(&stats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve job stats!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+func GetJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// RetryJob queues a failed job again with a fresh set of attempts.
+func RetryJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	if job.Status != "failed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only failed jobs can be retried"})
+		return
+	}
+
+	if err := db.DB.Model(&job).Updates(map[string]interface{}{
+		"status":      "queued",
+		"attempts":    0,
+		"run_at":      gorm.Expr("NOW()"),
+		"finished_at": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job queued."})
+}
+
+// DeleteJob discards a job that is not running.
+func DeleteJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	if job.Status == "running" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This job is running"})
+		return
+	}
+
+	if err := db.DB.Unscoped().Delete(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job deleted."})
+}
@@ -116,23 +116,22 @@ func GetProductivityTrendData(c *gin.Context) {
 		Duration int64  `json:"duration"`
 	}
 
+	// Focus time per day comes from the rollups kept by the analytics subscriber
 	var sessions []DailySession
 
 	if startDate != "" && endDate != "" {
-		if err := db.DB.Model(&models.TaskSession{}).
-			Select("DATE(start_time) as date, COALESCE(SUM(duration), 0) as duration").
-			Where("user_id = ? AND start_time BETWEEN ? AND ?", userId, startDate, endDate).
-			Group("DATE(start_time)").
+		if err := db.DB.Model(&models.DailyActivity{}).
+			Select("date, focus_seconds as duration").
+			Where("user_id = ? AND sessions > 0 AND date >= ? AND date < ?", userId, startDate, endDate).
 			Order("date").
 			Scan(&sessions).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task sessions!"})
 			return
 		}
 	} else {
-		if err := db.DB.Model(&models.TaskSession{}).
-			Select("DATE(start_time) as date, COALESCE(SUM(duration), 0) as duration").
-			Where("user_id = ?", userId).
-			Group("DATE(start_time)").
+		if err := db.DB.Model(&models.DailyActivity{}).
+			Select("date, focus_seconds as duration").
+			Where("user_id = ? AND sessions > 0", userId).
 			Order("date").
 			Scan(&sessions).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task sessions!"})
@@ -0,0 +1,367 @@
+package analytics
+
+import (
+	"master-management-api/internal/db"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTeamRangeDays = 30
+	defaultTrendWeeks    = 12
+	maxTrendWeeks        = 52
+)
+
+// Members who set hide_from_team_analytics never show up in per-member breakdowns,
+// and their focus sessions are left out entirely because those are personal data.
+// Task counts stay in team totals since the tasks belong to the workspace.
+const visibleMembers = `
+	SELECT m.user_id
+	FROM members m
+	LEFT JOIN user_settings us ON us.user_id = m.user_id AND us.deleted_at IS NULL
+	WHERE m.workspace_id = @workspace AND m.deleted_at IS NULL
+		AND COALESCE(us.hide_from_team_analytics, false) = false
+`
+
+// teamRange reads startDate / endDate (DD-MM-YYYY) and defaults to the last 30 days.
+// The returned end is exclusive.
+func teamRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -defaultTeamRangeDays)
+
+	if value := c.Query("startDate"); value != "" {
+		t, err := time.ParseInLocation("02-01-2006", value, now.Location())
+		if err != nil {
+			return start, end, false
+		}
+		start = t
+	}
+	if value := c.Query("endDate"); value != "" {
+		t, err := time.ParseInLocation("02-01-2006", value, now.Location())
+		if err != nil {
+			return start, end, false
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+
+	return start, end, end.After(start)
+}
+
+// teamFilter reads ?team=. Zero means the whole workspace.
+func teamFilter(c *gin.Context) (uint64, bool) {
+	value := c.Query("team")
+	if value == "" {
+		return 0, true
+	}
+	teamId, err := strconv.ParseUint(value, 10, 64)
+	return teamId, err == nil
+}
+
+func trendWeeks(c *gin.Context) (int, bool) {
+	value := c.Query("weeks")
+	if value == "" {
+		return defaultTrendWeeks, true
+	}
+	weeks, err := strconv.Atoi(value)
+	if err != nil || weeks < 1 || weeks > maxTrendWeeks {
+		return 0, false
+	}
+	return weeks, true
+}
+
+func GetTeamFocusTime(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	start, end, ok := teamRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	teamId, ok := teamFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team"})
+		return
+	}
+
+	params := map[string]interface{}{"workspace": workspaceId, "start": start, "end": end, "team": teamId}
+
+	type MemberFocus struct {
+		UserId   uint   `json:"user_id"`
+		Name     string `json:"name"`
+		Duration int64  `json:"duration"`
+		Sessions int64  `json:"sessions"`
+	}
+
+	var members []MemberFocus
+	if err := db.DB.Raw(`
+		SELECT s.user_id, CONCAT(u.first_name, ' ', u.last_name) AS name,
+			COALESCE(SUM(s.duration), 0) AS duration, COUNT(*) AS sessions
+		FROM task_sessions s
+		JOIN tasks t ON t.id = s.task_id
+		JOIN users u ON u.id = s.user_id
+		WHERE t.workspace_id = @workspace AND s.deleted_at IS NULL
+			AND s.start_time >= @start AND s.start_time < @end
+			AND s.user_id IN (`+visibleMembers+`)
+			AND (@team = 0 OR s.user_id IN (SELECT user_id FROM team_members WHERE team_id = @team AND deleted_at IS NULL))
+		GROUP BY s.user_id, u.first_name, u.last_name
+		ORDER BY duration DESC
+	`, params).Scan(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch focus time!"})
+		return
+	}
+
+	type CategoryFocus struct {
+		Category string `json:"category"`
+		Duration int64  `json:"duration"`
+	}
+
+	var categories []CategoryFocus
+	if err := db.DB.Raw(`
+		SELECT COALESCE(NULLIF(t.category, ''), 'Uncategorized') AS category, COALESCE(SUM(s.duration), 0) AS duration
+		FROM task_sessions s
+		JOIN tasks t ON t.id = s.task_id
+		WHERE t.workspace_id = @workspace AND s.deleted_at IS NULL
+			AND s.start_time >= @start AND s.start_time < @end
+			AND s.user_id IN (`+visibleMembers+`)
+			AND (@team = 0 OR s.user_id IN (SELECT user_id FROM team_members WHERE team_id = @team AND deleted_at IS NULL))
+		GROUP BY 1
+		ORDER BY duration DESC
+	`, params).Scan(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch focus time!"})
+		return
+	}
+
+	var total int64
+	for _, member := range members {
+		total += member.Duration
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":      total,
+		"members":    members,
+		"categories": categories,
+	})
+}
+
+func GetTeamCompletedPerWeek(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	weeks, ok := trendWeeks(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid number of weeks"})
+		return
+	}
+	teamId, ok := teamFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team"})
+		return
+	}
+
+	params := map[string]interface{}{"workspace": workspaceId, "weeks": weeks, "team": teamId}
+
+	type WeeklyCount struct {
+		Week      string `json:"week"`
+		Completed int64  `json:"completed"`
+	}
+
+	var team []WeeklyCount
+	if err := db.DB.Raw(`
+		SELECT TO_CHAR(w.week, 'YYYY-MM-DD') AS week, COUNT(t.id) AS completed
+		FROM generate_series(
+			DATE_TRUNC('week', NOW()) - (@weeks - 1) * INTERVAL '1 week',
+			DATE_TRUNC('week', NOW()),
+			INTERVAL '1 week'
+		) AS w(week)
+		LEFT JOIN tasks t ON t.workspace_id = @workspace AND t.deleted_at IS NULL
+			AND t.type = 'task' AND t.status = 'completed'
+			AND (@team = 0 OR t.team_id = @team)
+			AND t.completed_at >= w.week AND t.completed_at < w.week + INTERVAL '1 week'
+		GROUP BY w.week
+		ORDER BY w.week
+	`, params).Scan(&team).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completed tasks!"})
+		return
+	}
+
+	type MemberWeeklyCount struct {
+		UserId    uint   `json:"user_id"`
+		Name      string `json:"name"`
+		Week      string `json:"week"`
+		Completed int64  `json:"completed"`
+	}
+
+	// Completed tasks are credited to each assignee, or to the creator when unassigned
+	var members []MemberWeeklyCount
+	if err := db.DB.Raw(`
+		WITH credited AS (
+			SELECT t.id, t.completed_at,
+				CASE
+					WHEN jsonb_typeof(t.assignees::jsonb) = 'array' AND jsonb_array_length(t.assignees::jsonb) > 0
+					THEN t.assignees::jsonb
+					ELSE jsonb_build_array(t.user_id)
+				END AS credited_to
+			FROM tasks t
+			WHERE t.workspace_id = @workspace AND t.deleted_at IS NULL
+				AND t.type = 'task' AND t.status = 'completed'
+				AND (@team = 0 OR t.team_id = @team)
+				AND t.completed_at >= DATE_TRUNC('week', NOW()) - (@weeks - 1) * INTERVAL '1 week'
+		)
+		SELECT (a.value)::bigint AS user_id, CONCAT(u.first_name, ' ', u.last_name) AS name,
+			TO_CHAR(DATE_TRUNC('week', c.completed_at), 'YYYY-MM-DD') AS week, COUNT(*) AS completed
+		FROM credited c
+		CROSS JOIN LATERAL jsonb_array_elements_text(c.credited_to) a
+		JOIN users u ON u.id = (a.value)::bigint
+		WHERE (a.value)::bigint IN (`+visibleMembers+`)
+		GROUP BY 1, 2, 3
+		ORDER BY 3, 1
+	`, params).Scan(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completed tasks!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"weeks":   team,
+		"members": members,
+	})
+}
+
+func GetTeamGoalProgress(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	teamId, ok := teamFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team"})
+		return
+	}
+
+	type GoalProgress struct {
+		ID                uint       `json:"id"`
+		Title             string     `json:"title"`
+		Status            string     `json:"status"`
+		Progress          float64    `json:"progress"`
+		TargetValue       *float64   `json:"target_value"`
+		TargetType        *string    `json:"target_type"`
+		TargetProgress    *float64   `json:"target_progress"`
+		DueDate           *time.Time `json:"due_date"`
+		SubGoals          int64      `json:"sub_goals"`
+		CompletedSubGoals int64      `json:"completed_sub_goals"`
+		Contributors      int64      `json:"contributors"`
+	}
+
+	var goals []GoalProgress
+	if err := db.DB.Raw(`
+		SELECT
+			g.id, g.title, g.status, COALESCE(g.progress, 0) AS progress,
+			g.target_value, g.target_type, g.target_progress, g.due_date,
+			(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = g.id AND s.deleted_at IS NULL) AS sub_goals,
+			(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = g.id AND s.deleted_at IS NULL AND s.status = 'completed') AS completed_sub_goals,
+			(SELECT COUNT(DISTINCT h.user_id) FROM task_histories h WHERE h.task_id = g.id AND h.deleted_at IS NULL
+				AND h.user_id IN (`+visibleMembers+`)) AS contributors
+		FROM tasks g
+		WHERE g.workspace_id = @workspace AND g.deleted_at IS NULL AND g.type = 'goal' AND g.parent_id IS NULL
+			AND (@team = 0 OR g.team_id = @team)
+		ORDER BY g.due_date ASC NULLS LAST, g.created_at DESC
+	`, map[string]interface{}{"workspace": workspaceId, "team": teamId}).Scan(&goals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goal progress!"})
+		return
+	}
+
+	var completed, atRisk int64
+	var progressSum float64
+	for _, goal := range goals {
+		progressSum += goal.Progress
+		if goal.Status == "completed" {
+			completed++
+		} else if goal.DueDate != nil && goal.DueDate.Before(time.Now().AddDate(0, 0, 7)) && goal.Progress < 75 {
+			// Due within a week and still far from done
+			atRisk++
+		}
+	}
+
+	var averageProgress float64
+	if len(goals) > 0 {
+		averageProgress = progressSum / float64(len(goals))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": gin.H{
+			"total":            len(goals),
+			"completed":        completed,
+			"at_risk":          atRisk,
+			"average_progress": averageProgress,
+		},
+		"goals": goals,
+	})
+}
+
+func GetTeamThroughput(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	weeks, ok := trendWeeks(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid number of weeks"})
+		return
+	}
+	teamId, ok := teamFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team"})
+		return
+	}
+
+	type WeeklyThroughput struct {
+		Week           string  `json:"week"`
+		Created        int64   `json:"created"`
+		Completed      int64   `json:"completed"`
+		AvgCycleHours  float64 `json:"avg_cycle_hours"`
+		OpenAtWeekEnd  int64   `json:"open_at_week_end"`
+		CompletionRate float64 `json:"completion_rate" gorm:"-"`
+	}
+
+	var trend []WeeklyThroughput
+	if err := db.DB.Raw(`
+		WITH weeks AS (
+			SELECT week FROM generate_series(
+				DATE_TRUNC('week', NOW()) - (@weeks - 1) * INTERVAL '1 week',
+				DATE_TRUNC('week', NOW()),
+				INTERVAL '1 week'
+			) AS week
+		),
+		workspace_tasks AS (
+			SELECT id, created_at, completed_at, status, deleted_at
+			FROM tasks
+			WHERE workspace_id = @workspace AND type = 'task' AND parent_id IS NULL
+				AND (@team = 0 OR team_id = @team)
+		)
+		SELECT
+			TO_CHAR(w.week, 'YYYY-MM-DD') AS week,
+			(SELECT COUNT(*) FROM workspace_tasks t
+				WHERE t.deleted_at IS NULL AND t.created_at >= w.week AND t.created_at < w.week + INTERVAL '1 week') AS created,
+			(SELECT COUNT(*) FROM workspace_tasks t
+				WHERE t.deleted_at IS NULL AND t.status = 'completed'
+					AND t.completed_at >= w.week AND t.completed_at < w.week + INTERVAL '1 week') AS completed,
+			COALESCE((SELECT AVG(EXTRACT(EPOCH FROM (t.completed_at - t.created_at)) / 3600) FROM workspace_tasks t
+				WHERE t.deleted_at IS NULL AND t.status = 'completed'
+					AND t.completed_at >= w.week AND t.completed_at < w.week + INTERVAL '1 week'), 0) AS avg_cycle_hours,
+			(SELECT COUNT(*) FROM workspace_tasks t
+				WHERE t.created_at < w.week + INTERVAL '1 week'
+					AND (t.deleted_at IS NULL OR t.deleted_at >= w.week + INTERVAL '1 week')
+					AND (t.completed_at IS NULL OR t.completed_at >= w.week + INTERVAL '1 week')) AS open_at_week_end
+		FROM weeks w
+		ORDER BY w.week
+	`, map[string]interface{}{"workspace": workspaceId, "weeks": weeks, "team": teamId}).Scan(&trend).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch throughput!"})
+		return
+	}
+
+	for i := range trend {
+		if trend[i].Created > 0 {
+			trend[i].CompletionRate = float64(trend[i].Completed) / float64(trend[i].Created) * 100
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"weeks": trend})
+}
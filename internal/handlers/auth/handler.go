@@ -3,16 +3,95 @@ package auth
 import (
 	"master-management-api/internal/db"
 	"master-management-api/internal/handlers/settings"
+	"master-management-api/internal/middleware"
 	"master-management-api/internal/models"
+	"master-management-api/internal/utils"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const (
+	maxFailedLogins = 5
+	baseLockout     = 30 * time.Second
+	maxLockout      = time.Hour
+)
+
+// issueToken starts a session for the user, signs a token for it and sets it as the
+// Authorization cookie.
+func issueToken(c *gin.Context, userId uint) error {
+	tokenId, err := utils.GenerateToken(24)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(time.Hour * 24 * 30)
+	session := models.AuthSession{
+		UserId:    userId,
+		TokenId:   tokenId,
+		UserAgent: c.Request.UserAgent(),
+		IpAddress: c.ClientIP(),
+		ExpiresAt: expiresAt,
+	}
+	if err := db.DB.Create(&session).Error; err != nil {
+		return err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userId,
+		"jti": tokenId,
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return err
+	}
+
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie("Authorization", tokenString, 3600*24*30, "", "", true, true)
+
+	return issueCSRFToken(c)
+}
+
+// issueCSRFToken sets the double-submit cookie and hands the same value to the client
+// in a header, since a cross-site frontend can't read our cookies.
+func issueCSRFToken(c *gin.Context) error {
+	csrfToken, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(middleware.CSRFCookieName, csrfToken, 3600*24*30, "", "", true, false)
+	c.Header(middleware.CSRFHeaderName, csrfToken)
+
+	return nil
+}
+
+// GetCSRFToken returns the current CSRF token, issuing a new one when the cookie is missing.
+func GetCSRFToken(c *gin.Context) {
+	csrfToken, err := c.Cookie(middleware.CSRFCookieName)
+	if err != nil || csrfToken == "" {
+		if err := issueCSRFToken(c); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create CSRF token"})
+			return
+		}
+		csrfToken = c.Writer.Header().Get(middleware.CSRFHeaderName)
+	}
+
+	c.Header(middleware.CSRFHeaderName, csrfToken)
+	c.JSON(http.StatusOK, gin.H{"csrf_token": csrfToken})
+}
+
 func SignUp(c *gin.Context) {
 	// Get email and password
 	var body struct {
@@ -29,6 +108,21 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+	if body.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Email is required",
+		})
+		return
+	}
+
+	if err := utils.ValidatePassword(body.Password, body.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// Hash the password
 	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
 	if err != nil {
@@ -59,25 +153,14 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
-	// Generate a jwt token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 30).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
-	if err != nil {
+	// Generate a jwt token and send it back
+	if err := issueToken(c, user.ID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to create token",
 		})
 		return
 	}
 
-	// send it back
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("Authorization", tokenString, 3600*24*30, "", "", true, true)
-
 	// Respond
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User created successfully",
@@ -98,9 +181,10 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Look up for requested user
+	// Look up for requested user. Emails are matched case-insensitively, the same way
+	// the per-account rate limit keys them.
 	var user models.User
-	db.DB.First(&user, "email = ?", body.Email)
+	db.DB.First(&user, "LOWER(email) = ?", strings.ToLower(strings.TrimSpace(body.Email)))
 
 	if user.ID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -109,37 +193,71 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		middleware.TooManyRequests(c, time.Until(*user.LockedUntil), "Account temporarily locked due to too many failed login attempts")
+		return
+	}
+
 	// Compare send in password with the saved password hash
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password))
 	if err != nil {
+		lockedFor := registerFailedLogin(&user)
+		if lockedFor > 0 {
+			middleware.TooManyRequests(c, lockedFor, "Account temporarily locked due to too many failed login attempts")
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid user or password",
 		})
 		return
 	}
 
-	// Generate a jwt token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 30).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		db.DB.Model(&user).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		})
+	}
 
-	if err != nil {
+	// Generate a jwt token and send it back
+	if err := issueToken(c, user.ID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to create token",
 		})
 		return
 	}
 
-	// send it back
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("Authorization", tokenString, 3600*24*30, "", "", true, true)
-
 	c.JSON(http.StatusOK, gin.H{})
 }
 
+// registerFailedLogin counts a failed attempt and locks the account once the limit is
+// reached, doubling the lockout for every further failure. The counter is incremented
+// in the database so concurrent attempts cannot overwrite each other.
+func registerFailedLogin(user *models.User) time.Duration {
+	if err := db.DB.Model(user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_login_attempts"}}}).
+		UpdateColumn("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error; err != nil {
+		return 0
+	}
+
+	if user.FailedLoginAttempts < maxFailedLogins {
+		return 0
+	}
+
+	lockedFor := baseLockout
+	for i := maxFailedLogins; i < int(user.FailedLoginAttempts) && lockedFor < maxLockout; i++ {
+		lockedFor *= 2
+	}
+	if lockedFor > maxLockout {
+		lockedFor = maxLockout
+	}
+	lockedUntil := time.Now().Add(lockedFor)
+	user.LockedUntil = &lockedUntil
+	db.DB.Model(user).UpdateColumn("locked_until", user.LockedUntil)
+
+	return lockedFor
+}
+
 func Validate(c *gin.Context) {
 	user, _ := c.Get("user")
 
@@ -148,11 +266,85 @@ func Validate(c *gin.Context) {
 	})
 }
 
+// revokeSessions revokes every active session of the user except the one with exceptTokenId.
+func revokeSessions(userId uint, exceptTokenId string) error {
+	return db.DB.Model(&models.AuthSession{}).
+		Where("user_id = ? AND token_id <> ? AND revoked_at IS NULL", userId, exceptTokenId).
+		Update("revoked_at", time.Now()).Error
+}
+
 func Logout(c *gin.Context) {
-	// Clear the Authorization cookie
+	if tokenId := c.GetString("tokenId"); tokenId != "" {
+		db.DB.Model(&models.AuthSession{}).
+			Where("token_id = ? AND revoked_at IS NULL", tokenId).
+			Update("revoked_at", time.Now())
+	}
+
+	// Clear the Authorization and CSRF cookies
 	c.SetCookie("Authorization", "", -1, "", "", true, true)
+	c.SetCookie(middleware.CSRFCookieName, "", -1, "", "", true, false)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
+
+func ChangePassword(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	// Accounts created through a login provider may not have a password yet
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.CurrentPassword)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+	}
+
+	if err := utils.ValidatePassword(body.NewPassword, user.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.NewPassword), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if err := db.DB.Model(&user).Updates(map[string]interface{}{
+		"password":            string(hash),
+		"password_changed_at": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	// Sign out everywhere else
+	currentTokenId := c.GetString("tokenId")
+	if err := revokeSessions(user.ID, currentTokenId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password updated but failed to sign out other sessions"})
+		return
+	}
+
+	// Tokens without a session are rejected once the password changes, so move the
+	// caller onto a tracked session
+	if currentTokenId == "" {
+		if err := issueToken(c, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
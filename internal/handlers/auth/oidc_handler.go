@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"master-management-api/internal/db"
+	"master-management-api/internal/handlers/settings"
+	"master-management-api/internal/models"
+	"master-management-api/pkg/oidc"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateTTL = 10 * time.Minute
+	// The binding cookie ties a pending authorization to the browser that started it,
+	// so a flow started by someone else cannot be completed in a victim's browser.
+	oidcBindingCookie = "oidc_binding"
+	oidcCookiePath    = "/auth/oidc"
+)
+
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oidc.ProviderNames()})
+}
+
+// createOIDCState stores the PKCE verifier and nonce for a pending authorization, binds
+// it to the browser with a cookie and returns the URL the browser should be sent to.
+func createOIDCState(c *gin.Context, provider *oidc.Provider, userId *uint) (string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.RandomString(48)
+	if err != nil {
+		return "", err
+	}
+	binding, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	// Clean up abandoned attempts while we are here
+	db.DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{})
+
+	record := models.OIDCState{
+		State:        state,
+		Provider:     provider.Name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BindingHash:  hashBinding(binding),
+		UserId:       userId,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := db.DB.Create(&record).Error; err != nil {
+		return "", err
+	}
+
+	// Lax so the cookie comes back on the top-level redirect from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, binding, int(oidcStateTTL.Seconds()), oidcCookiePath, "", true, true)
+
+	return provider.AuthCodeURL(state, nonce, verifier)
+}
+
+func OIDCLogin(c *gin.Context) {
+	provider, ok := oidc.GetProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	authURL, err := createOIDCState(c, provider, nil)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start login with provider"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func OIDCLink(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	provider, ok := oidc.GetProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	authURL, err := createOIDCState(c, provider, &userId)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start linking with provider"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+// oidcRedirect sends the browser back to the frontend, or answers with JSON when
+// OIDC_FRONTEND_REDIRECT_URL is not configured.
+func oidcRedirect(c *gin.Context, status int, errorMessage string) {
+	frontend := os.Getenv("OIDC_FRONTEND_REDIRECT_URL")
+	if frontend == "" {
+		if errorMessage != "" {
+			c.JSON(status, gin.H{"error": errorMessage})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Logged in successfully"})
+		return
+	}
+
+	if errorMessage != "" {
+		sep := "?"
+		if strings.Contains(frontend, "?") {
+			sep = "&"
+		}
+		frontend += sep + "error=" + url.QueryEscape(errorMessage)
+	}
+
+	c.Redirect(http.StatusFound, frontend)
+}
+
+func OIDCCallback(c *gin.Context) {
+	provider, ok := oidc.GetProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	if providerError := c.Query("error"); providerError != "" {
+		oidcRedirect(c, http.StatusBadRequest, "Login was cancelled or denied by the provider")
+		return
+	}
+
+	var state models.OIDCState
+	if err := db.DB.Where("state = ? AND provider = ?", c.Query("state"), provider.Name).First(&state).Error; err != nil {
+		oidcRedirect(c, http.StatusBadRequest, "Invalid or expired login attempt")
+		return
+	}
+	// A state can only be used once
+	db.DB.Unscoped().Delete(&state)
+
+	if time.Now().After(state.ExpiresAt) {
+		oidcRedirect(c, http.StatusBadRequest, "Invalid or expired login attempt")
+		return
+	}
+
+	binding, _ := c.Cookie(oidcBindingCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, "", -1, oidcCookiePath, "", true, true)
+	if binding == "" || subtle.ConstantTimeCompare([]byte(hashBinding(binding)), []byte(state.BindingHash)) != 1 {
+		oidcRedirect(c, http.StatusBadRequest, "This login was started in another browser")
+		return
+	}
+
+	rawIDToken, err := provider.Exchange(c.Query("code"), state.CodeVerifier)
+	if err != nil {
+		oidcRedirect(c, http.StatusBadGateway, "Failed to complete login with provider")
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, state.Nonce)
+	if err != nil {
+		oidcRedirect(c, http.StatusUnauthorized, "Failed to verify identity with provider")
+		return
+	}
+
+	var identity models.UserIdentity
+	err = db.DB.Where("provider = ? AND subject = ?", provider.Name, claims.Subject).First(&identity).Error
+	identityExists := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		oidcRedirect(c, http.StatusInternalServerError, "Failed to look up linked identity")
+		return
+	}
+
+	// Linking an additional identity to the signed in user
+	if state.UserId != nil {
+		if identityExists {
+			if identity.UserId != *state.UserId {
+				oidcRedirect(c, http.StatusConflict, "This identity is already linked to another account")
+				return
+			}
+			oidcRedirect(c, http.StatusOK, "")
+			return
+		}
+
+		if err := linkIdentity(*state.UserId, provider.Name, claims); err != nil {
+			oidcRedirect(c, http.StatusInternalServerError, "Failed to link identity")
+			return
+		}
+		oidcRedirect(c, http.StatusOK, "")
+		return
+	}
+
+	var user models.User
+	if identityExists {
+		if err := db.DB.First(&user, identity.UserId).Error; err != nil {
+			oidcRedirect(c, http.StatusUnauthorized, "Linked account no longer exists")
+			return
+		}
+	} else {
+		if claims.Email == "" || !claims.EmailVerified {
+			oidcRedirect(c, http.StatusForbidden, "The provider did not return a verified email address")
+			return
+		}
+
+		user, err = findOrCreateOIDCUser(claims)
+		if err != nil {
+			oidcRedirect(c, http.StatusInternalServerError, "Failed to create user")
+			return
+		}
+
+		if err := linkIdentity(user.ID, provider.Name, claims); err != nil {
+			oidcRedirect(c, http.StatusInternalServerError, "Failed to link identity")
+			return
+		}
+	}
+
+	if err := issueToken(c, user.ID); err != nil {
+		oidcRedirect(c, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+
+	oidcRedirect(c, http.StatusOK, "")
+}
+
+func linkIdentity(userId uint, provider string, claims *oidc.Claims) error {
+	identity := models.UserIdentity{
+		UserId:   userId,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+
+	return db.DB.Create(&identity).Error
+}
+
+// findOrCreateOIDCUser matches a verified email to an existing account, or signs up a
+// new passwordless user.
+func findOrCreateOIDCUser(claims *oidc.Claims) (models.User, error) {
+	var user models.User
+	err := db.DB.Where("LOWER(email) = LOWER(?)", claims.Email).First(&user).Error
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	firstName := claims.GivenName
+	lastName := claims.FamilyName
+	if firstName == "" && claims.Name != "" {
+		parts := strings.SplitN(claims.Name, " ", 2)
+		firstName = parts[0]
+		if len(parts) > 1 {
+			lastName = parts[1]
+		}
+	}
+
+	user = models.User{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     claims.Email,
+	}
+	if claims.Picture != "" {
+		user.AvatarUrl = &claims.Picture
+	}
+
+	if err := db.DB.Create(&user).Error; err != nil {
+		return user, err
+	}
+
+	if err := settings.CreateUserSettings(user.ID); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func GetIdentities(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var identities []models.UserIdentity
+	if err := db.DB.Where("user_id = ?", userId).Order("created_at ASC").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve linked identities!"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, gin.H{
+			"id":         identity.ID,
+			"provider":   identity.Provider,
+			"email":      identity.Email,
+			"created_at": identity.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": response})
+}
+
+func UnlinkIdentity(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	identityId := c.Param("identityId")
+
+	var identity models.UserIdentity
+	if err := db.DB.Where("id = ? AND user_id = ?", identityId, user.ID).First(&identity).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		return
+	}
+
+	// Don't lock the user out of their account
+	if user.Password == "" {
+		var identityCount int64
+		db.DB.Model(&models.UserIdentity{}).Where("user_id = ?", user.ID).Count(&identityCount)
+		if identityCount <= 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot unlink the only sign in method. Set a password first"})
+			return
+		}
+	}
+
+	if err := db.DB.Unscoped().Delete(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked successfully"})
+}
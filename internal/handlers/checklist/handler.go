@@ -2,11 +2,14 @@ package checklist
 
 import (
 	"master-management-api/internal/db"
+	"master-management-api/internal/events"
 	"master-management-api/internal/models"
 	"master-management-api/internal/utils"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func CreateChecklist(c *gin.Context) {
@@ -30,14 +33,19 @@ func CreateChecklist(c *gin.Context) {
 		Completed: false,
 	}
 
-	if db.DB.Create(&checklist).Error != nil {
+	var outbox events.Batch
+	if db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&checklist).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.ChecklistCreated{Checklist: checklist, ActorId: userId})
+	}) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist!"})
 		return
 	}
+	outbox.Flush()
 
-	var task models.Task
-	db.DB.Where("id = ?", body.TaskId).First(&task)
-	progress, err := utils.RecalculateProgress(body.TaskId)
+	progress, err := utils.Progress(body.TaskId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 		return
@@ -106,20 +114,33 @@ func UpdateChecklist(c *gin.Context) {
 	if body.Title != "" {
 		checklist.Title = body.Title
 	}
+	var event events.Event = events.ChecklistUpdated{Checklist: checklist, ActorId: userId}
 	if body.Completed != nil {
+		if *body.Completed && !checklist.Completed {
+			currentTime := time.Now()
+			checklist.CompletedAt = &currentTime
+		} else if !*body.Completed {
+			checklist.CompletedAt = nil
+		}
 		checklist.Completed = *body.Completed
+		event = events.ChecklistToggled{Checklist: checklist, ActorId: userId}
 	}
 
-	if db.DB.Save(&checklist).Error != nil {
+	var outbox events.Batch
+	if db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&checklist).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, event)
+	}) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save checklist!"})
 		return
 	}
+	outbox.Flush()
 
-	var task models.Task
 	var taskProgress float64
 	if body.Completed != nil {
-		db.DB.Where("id = ?", checklist.TaskId).First(&task)
-		progress, err := utils.RecalculateProgress(task.ID)
+		progress, err := utils.Progress(checklist.TaskId)
 		taskProgress = progress
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
@@ -147,14 +168,19 @@ func DeleteChecklist(c *gin.Context) {
 		return
 	}
 
-	if db.DB.Delete(&checklist).Error != nil {
+	var outbox events.Batch
+	if db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&checklist).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.ChecklistDeleted{Checklist: checklist, ActorId: userId})
+	}) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist!"})
 		return
 	}
+	outbox.Flush()
 
-	var task models.Task
-	db.DB.Where("id = ?", checklist.TaskId).First(&task)
-	progress, err := utils.RecalculateProgress(task.ID)
+	progress, err := utils.Progress(checklist.TaskId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 		return
@@ -191,14 +217,24 @@ func SaveChecklists(c *gin.Context) {
 		})
 	}
 
-	if db.DB.Save(&checklists).Error != nil {
+	var outbox events.Batch
+	if db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&checklists).Error; err != nil {
+			return err
+		}
+		for _, checklist := range checklists {
+			if err := outbox.Record(tx, events.ChecklistCreated{Checklist: checklist, ActorId: userId}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist!"})
 		return
 	}
+	outbox.Flush()
 
-	var task models.Task
-	db.DB.Where("id = ?", body[0].TaskId).First(&task)
-	progress, err := utils.RecalculateProgress(task.ID)
+	progress, err := utils.Progress(body[0].TaskId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 		return
@@ -0,0 +1,114 @@
+package digest
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/digest"
+	"master-management-api/internal/models"
+	"master-management-api/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 30
+	maxPageSize     = 100
+)
+
+// GetDigests lists the stored digests newest first. The rendered versions are left
+// out, GetDigest returns them.
+func GetDigests(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	limit := defaultPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageSize)
+	}
+
+	query := db.DB.Where("user_id = ?", userId)
+	if period := c.Query("period"); period != "" {
+		if period != digest.Daily && period != digest.Weekly {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be either 'daily' or 'weekly'"})
+			return
+		}
+		query = query.Where("period = ?", period)
+	}
+
+	digests := []models.Digest{}
+	if err := query.Order("period_start DESC, id DESC").Limit(limit).Find(&digests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve digests!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": digests})
+}
+
+func GetDigest(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var stored models.Digest
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("digestId"), userId).First(&stored).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Digest not found"})
+		return
+	}
+
+	if c.Query("format") == "html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(stored.HTML))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": stored,
+		"html": stored.HTML,
+		"text": stored.Text,
+	})
+}
+
+// PreviewDigest builds the running day or week up to now without storing it.
+func PreviewDigest(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+	userId := user.ID
+
+	period := c.DefaultQuery("period", digest.Daily)
+	if period != digest.Daily && period != digest.Weekly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be either 'daily' or 'weekly'"})
+		return
+	}
+
+	var settings models.UserSettings
+	db.DB.Where("user_id = ?", userId).First(&settings)
+
+	start, end := digest.PeriodSoFar(period, settings, utils.Location(user.TimeZone), time.Now())
+	summary, err := digest.Build(userId, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build digest!"})
+		return
+	}
+	text, html, err := digest.Render(period, start, end, summary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build digest!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": models.Digest{
+			UserId:      userId,
+			Period:      period,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Summary:     summary,
+		},
+		"html": html,
+		"text": text,
+	})
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"time"
+)
+
+// FormatVersion is bumped whenever the layout of export.json changes.
+const FormatVersion = 3
+
+type exportedUser struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Theme     string    `json:"theme"`
+	JobTitle  *string   `json:"job_title"`
+	TimeZone  *string   `json:"time_zone"`
+	Language  string    `json:"language"`
+	Bio       string    `json:"bio"`
+	Favorites []uint    `json:"favorites"`
+	AvatarUrl *string   `json:"avatar_url"`
+	Company   *string   `json:"company"`
+}
+
+type exportedTask struct {
+	models.Task
+	Checklists []models.Checklist `json:"checklists"`
+	Notes      []models.Note      `json:"notes"`
+	Subtasks   []*exportedTask    `json:"subtasks"`
+}
+
+type exportedSession struct {
+	ID        uint       `json:"id"`
+	TaskID    uint       `json:"task_id"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Duration  int64      `json:"duration"`
+}
+
+type exportedMembership struct {
+	models.Member
+	WorkspaceName string `json:"workspace_name"`
+}
+
+type exportDocument struct {
+	FormatVersion  int                   `json:"format_version"`
+	GeneratedAt    time.Time             `json:"generated_at"`
+	User           exportedUser          `json:"user"`
+	Settings       *models.UserSettings  `json:"settings"`
+	Identities     []models.UserIdentity `json:"identities"`
+	Projects       []models.Project      `json:"projects"`
+	Tasks          []*exportedTask       `json:"tasks"`
+	TaskHistory    []models.TaskHistory  `json:"task_history"`
+	TaskSessions   []exportedSession     `json:"task_sessions"`
+	Comments       []models.Comment      `json:"comments"`
+	Memberships    []exportedMembership  `json:"memberships"`
+	OtherNotes     []models.Note         `json:"other_notes"`
+	OtherChecklist []models.Checklist    `json:"other_checklists"`
+}
+
+func collect(userId uint) (*exportDocument, error) {
+	var user models.User
+	if err := db.DB.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+
+	doc := &exportDocument{
+		FormatVersion: FormatVersion,
+		GeneratedAt:   time.Now().UTC(),
+		User: exportedUser{
+			ID:        user.ID,
+			CreatedAt: user.CreatedAt,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     user.Email,
+			Theme:     user.Theme,
+			JobTitle:  user.JobTitle,
+			TimeZone:  user.TimeZone,
+			Language:  user.Language,
+			Bio:       user.Bio,
+			Favorites: user.Favorites,
+			AvatarUrl: user.AvatarUrl,
+			Company:   user.Company,
+		},
+		Tasks: []*exportedTask{},
+	}
+
+	var settings models.UserSettings
+	if err := db.DB.Where("user_id = ?", userId).First(&settings).Error; err == nil {
+		doc.Settings = &settings
+	}
+
+	if err := db.DB.Where("user_id = ?", userId).Find(&doc.Identities).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []models.Task
+	if err := db.DB.Where("user_id = ?", userId).Order("id ASC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	taskIds := []uint{0}
+	byId := map[uint]*exportedTask{}
+	for _, task := range tasks {
+		taskIds = append(taskIds, task.ID)
+		byId[task.ID] = &exportedTask{
+			Task:       task,
+			Checklists: []models.Checklist{},
+			Notes:      []models.Note{},
+			Subtasks:   []*exportedTask{},
+		}
+	}
+
+	// Nest subtasks under their parents when the parent is part of the export too
+	for _, task := range tasks {
+		entry := byId[task.ID]
+		if task.ParentId != nil {
+			if parent, ok := byId[*task.ParentId]; ok {
+				parent.Subtasks = append(parent.Subtasks, entry)
+				continue
+			}
+		}
+		doc.Tasks = append(doc.Tasks, entry)
+	}
+
+	var checklists []models.Checklist
+	if err := db.DB.Where("task_id IN ? OR user_id = ?", taskIds, userId).Order("id ASC").Find(&checklists).Error; err != nil {
+		return nil, err
+	}
+	for _, checklist := range checklists {
+		if task, ok := byId[checklist.TaskId]; ok {
+			task.Checklists = append(task.Checklists, checklist)
+		} else {
+			doc.OtherChecklist = append(doc.OtherChecklist, checklist)
+		}
+	}
+
+	var notes []models.Note
+	if err := db.DB.Where("user_id = ?", userId).Order("id ASC").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if task, ok := byId[note.TaskId]; ok {
+			task.Notes = append(task.Notes, note)
+		} else {
+			doc.OtherNotes = append(doc.OtherNotes, note)
+		}
+	}
+
+	if err := db.DB.Where("user_id = ? OR task_id IN ?", userId, taskIds).Order("id ASC").Find(&doc.TaskHistory).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Model(&models.TaskSession{}).
+		Select("id, task_id, start_time, end_time, duration").
+		Where("user_id = ?", userId).
+		Order("id ASC").
+		Scan(&doc.TaskSessions).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Where("user_id = ?", userId).Order("id ASC").Find(&doc.Comments).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Where("workspace_id IS NULL AND user_id = ?", userId).Order("id ASC").Find(&doc.Projects).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.DB.Table("members").
+		Select("members.*, workspaces.name as workspace_name").
+		Joins("JOIN workspaces ON workspaces.id = members.workspace_id").
+		Where("members.user_id = ? AND members.deleted_at IS NULL", userId).
+		Scan(&doc.Memberships).Error; err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// BuildArchive assembles everything tied to the user into a zip holding export.json.
+func BuildArchive(userId uint) ([]byte, error) {
+	doc, err := collect(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	readme, err := archive.Create("README.txt")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(readme, "Master Management data export\nFormat version: %d\nGenerated at: %s\n\nexport.json contains your profile, settings, personal projects, tasks and goals (with subtasks, checklists and notes), task history, focus sessions, comments and workspace memberships.\n",
+		FormatVersion, doc.GeneratedAt.Format(time.RFC3339))
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,220 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/jobs"
+	"master-management-api/internal/models"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	archiveRetention = 7 * 24 * time.Hour
+	downloadLinkTTL  = 15 * time.Minute
+)
+
+// RunExport builds the archive for a pending export and stores the result.
+func RunExport(exportId uint) error {
+	var export models.DataExport
+	if err := db.DB.First(&export, exportId).Error; err != nil {
+		return err
+	}
+
+	db.DB.Model(&export).Update("status", "processing")
+
+	archive, err := BuildArchive(export.UserId)
+	if err != nil {
+		db.DB.Model(&export).Updates(map[string]interface{}{
+			"status": "failed",
+			"error":  "Failed to assemble export",
+		})
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(archiveRetention)
+	return db.DB.Model(&export).Updates(map[string]interface{}{
+		"status":       "completed",
+		"archive":      archive,
+		"size":         len(archive),
+		"error":        "",
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// ResumeOrphanedExports queues a job for pending exports that have none, which are
+// exports requested before they were run through the job queue.
+func ResumeOrphanedExports() error {
+	var exports []models.DataExport
+	if err := db.DB.Select("id").
+		Where("status IN ?", []string{"pending", "processing"}).
+		Where("NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.kind = ? AND (jobs.payload::jsonb ->> 'export_id')::bigint = data_exports.id)", jobs.ExportData{}.JobKind()).
+		Find(&exports).Error; err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if err := jobs.Enqueue(db.DB, jobs.ExportData{ExportId: export.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func signingKey() []byte {
+	if key := os.Getenv("EXPORT_SIGNING_SECRET"); key != "" {
+		return []byte(key)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+func sign(exportId uint, expires int64) string {
+	mac := hmac.New(sha256.New, signingKey())
+	fmt.Fprintf(mac, "%d:%d", exportId, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func downloadURL(exportId uint) (string, time.Time) {
+	expires := time.Now().Add(downloadLinkTTL)
+
+	params := url.Values{}
+	params.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	params.Set("signature", sign(exportId, expires.Unix()))
+
+	return fmt.Sprintf("/exports/%d/download?%s", exportId, params.Encode()), expires
+}
+
+func exportResponse(export models.DataExport) gin.H {
+	response := gin.H{
+		"id":           export.ID,
+		"status":       export.Status,
+		"size":         export.Size,
+		"error":        export.Error,
+		"created_at":   export.CreatedAt,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+
+	if export.Status == "completed" && export.ExpiresAt != nil && time.Now().Before(*export.ExpiresAt) {
+		link, linkExpiresAt := downloadURL(export.ID)
+		response["download_url"] = link
+		response["download_url_expires_at"] = linkExpiresAt
+	}
+
+	return response
+}
+
+func RequestExport(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	// Drop archives that can no longer be downloaded
+	db.DB.Unscoped().Where("user_id = ? AND expires_at < ?", userId, time.Now()).Delete(&models.DataExport{})
+
+	var running int64
+	db.DB.Model(&models.DataExport{}).Where("user_id = ? AND status IN ?", userId, []string{"pending", "processing"}).Count(&running)
+	if running > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An export is already in progress"})
+		return
+	}
+
+	export := models.DataExport{
+		UserId: userId,
+		Status: "pending",
+	}
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&export).Error; err != nil {
+			return err
+		}
+		return jobs.Enqueue(tx, jobs.ExportData{ExportId: export.ID})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export!"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Export started.",
+		"data":    exportResponse(export),
+	})
+}
+
+func GetExports(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var exports []models.DataExport
+	if err := db.DB.Omit("archive").Where("user_id = ?", userId).Order("created_at DESC").Find(&exports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exports!"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(exports))
+	for _, export := range exports {
+		response = append(response, exportResponse(export))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+func GetExport(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	exportId := c.Param("exportId")
+
+	var export models.DataExport
+	if err := db.DB.Omit("archive").Where("id = ? AND user_id = ?", exportId, userId).First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": exportResponse(export)})
+}
+
+// DownloadExport serves the archive to anyone holding a valid signed link, so it
+// sits outside RequireAuth.
+func DownloadExport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("exportId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		c.JSON(http.StatusGone, gin.H{"error": "Download link has expired"})
+		return
+	}
+
+	expected := sign(uint(id), expires)
+	if !hmac.Equal([]byte(expected), []byte(c.Query("signature"))) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid download link"})
+		return
+	}
+
+	var export models.DataExport
+	if err := db.DB.Where("id = ? AND status = 'completed'", id).First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	if export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired"})
+		return
+	}
+
+	filename := fmt.Sprintf("master-management-export-%s.zip", export.CompletedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/zip", export.Archive)
+}
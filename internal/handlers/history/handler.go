@@ -5,6 +5,7 @@ import (
 	"master-management-api/internal/db"
 	"master-management-api/internal/models"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -75,3 +76,23 @@ func LogHistory(action string, before string, after string, taskId uint, userId
 	}
 
 }
+
+// LogAssigneeChanges records one entry per user added to or removed from the task.
+func LogAssigneeChanges(before []uint, after []uint, taskId uint, userId uint) {
+	previous := map[uint]bool{}
+	for _, id := range before {
+		previous[id] = true
+	}
+	current := map[uint]bool{}
+	for _, id := range after {
+		current[id] = true
+		if !previous[id] {
+			LogHistory("assignee_added", "", strconv.FormatUint(uint64(id), 10), taskId, userId)
+		}
+	}
+	for _, id := range before {
+		if !current[id] {
+			LogHistory("assignee_removed", strconv.FormatUint(uint64(id), 10), "", taskId, userId)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package note
 
 import (
 	"master-management-api/internal/db"
+	"master-management-api/internal/events"
 	"master-management-api/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func AddNote(c *gin.Context) {
@@ -48,10 +50,17 @@ func AddNote(c *gin.Context) {
 		UserId:      user.ID,
 	}
 
-	if db.DB.Create(&note).Error != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.NoteCreated{Note: note, ActorId: user.ID})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add note!"})
 		return
 	}
+	outbox.Flush()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Note added successfully!", "data": note})
 }
@@ -119,10 +128,18 @@ func UpdateNote(c *gin.Context) {
 		note.Variant = body.Variant
 	}
 
-	if db.DB.Save(&note).Error != nil {
+	userData, _ := c.Get("user")
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&note).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.NoteUpdated{Note: note, ActorId: userData.(models.User).ID})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note!"})
 		return
 	}
+	outbox.Flush()
 
 	noteResponse := models.Note{
 		Content:     note.Content,
@@ -150,10 +167,18 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
-	if db.DB.Delete(&note).Error != nil {
+	userData, _ := c.Get("user")
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&note).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.NoteDeleted{Note: note, ActorId: userData.(models.User).ID})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete note!"})
 		return
 	}
+	outbox.Flush()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Note deleted successfully."})
 }
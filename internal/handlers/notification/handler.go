@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 30
+	maxPageSize     = 100
+)
+
+func unreadCount(userId uint) (int64, error) {
+	var count int64
+	err := db.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userId).Count(&count).Error
+	return count, err
+}
+
+// GetNotifications returns the inbox newest first. Older pages are fetched with
+// ?before=<id of the last notification>.
+func GetNotifications(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	limit := defaultPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageSize)
+	}
+
+	query := db.DB.Where("user_id = ?", userId)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if kinds := c.QueryArray("kind"); len(kinds) > 0 {
+		query = query.Where("kind IN ?", kinds)
+	}
+	if before := c.Query("before"); before != "" {
+		if _, err := strconv.ParseUint(before, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	notifications := []models.Notification{}
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications!"})
+		return
+	}
+
+	var nextCursor *uint
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		nextCursor = &notifications[limit-1].ID
+	}
+
+	unread, err := unreadCount(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         notifications,
+		"unread_count": unread,
+		"next_cursor":  nextCursor,
+	})
+}
+
+func GetUnreadCount(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	unread, err := unreadCount(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+func MarkRead(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var notification models.Notification
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("notificationId"), userId).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		if err := db.DB.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification!"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read."})
+}
+
+func MarkAllRead(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	result := db.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All notifications marked as read.",
+		"updated": result.RowsAffected,
+	})
+}
@@ -0,0 +1,254 @@
+package profile
+
+import (
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/middleware"
+	"master-management-api/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// transferManagedWorkspaces hands every workspace managed by the user to the next
+// manager, admin, member or viewer, longest standing first. Guests never take over,
+// so it returns the workspaces where nobody but guests is left.
+func transferManagedWorkspaces(tx *gorm.DB, userId uint) ([]uint, error) {
+	var workspaces []models.Workspace
+	if err := tx.Where("manager_id = ?", userId).Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+
+	orphaned := []uint{}
+	for _, workspace := range workspaces {
+		var successor models.Member
+		err := tx.Where("workspace_id = ? AND user_id <> ? AND role <> 'guest'", workspace.ID, userId).
+			Order(`CASE
+				WHEN role = 'manager' THEN 1
+				WHEN role = 'admin' THEN 2
+				WHEN role = 'member' THEN 3
+				ELSE 4
+			END, joined_at ASC`).
+			First(&successor).Error
+		if err == gorm.ErrRecordNotFound {
+			orphaned = append(orphaned, workspace.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tx.Model(&workspace).Update("manager_id", successor.UserId).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&successor).Update("role", "manager").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return orphaned, nil
+}
+
+// deleteUserData removes everything owned by the user and anonymises the account
+// so shared workspace tasks and history keep pointing at a tombstone.
+func deleteUserData(tx *gorm.DB, user models.User) error {
+	orphaned, err := transferManagedWorkspaces(tx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// Personal tasks, tasks of workspaces that would be left empty, and all of their subtasks
+	var taskIds []uint
+	if err := tx.Raw(`
+		WITH RECURSIVE doomed AS (
+			SELECT id FROM tasks
+			WHERE (user_id = ? AND workspace_id IS NULL AND parent_id IS NULL) OR workspace_id IN ?
+			UNION
+			SELECT t.id FROM tasks t JOIN doomed d ON t.parent_id = d.id
+		)
+		SELECT id FROM doomed
+	`, user.ID, append(orphaned, 0)).Scan(&taskIds).Error; err != nil {
+		return err
+	}
+	taskIds = append(taskIds, 0)
+
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ?", taskIds, user.ID).Delete(&models.Checklist{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ?", taskIds, user.ID).Delete(&models.Note{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ?", taskIds).Delete(&models.TaskHistory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ? OR mentioned_by = ?", taskIds, user.ID, user.ID).Delete(&models.Mention{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ?", taskIds, user.ID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ?", taskIds, user.ID).Delete(&models.TaskSession{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ? OR user_id = ?", taskIds, user.ID).Delete(&models.ShareLink{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.FeedToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("task_id IN ?", taskIds).Delete(&models.SprintTask{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", taskIds).Delete(&models.Task{}).Error; err != nil {
+		return err
+	}
+
+	// Drop the user from shared task assignments
+	if err := tx.Exec(`
+		UPDATE tasks
+		SET assignees = (
+			SELECT COALESCE(jsonb_agg(a), '[]'::jsonb)::text
+			FROM jsonb_array_elements(tasks.assignees::jsonb) a
+			WHERE a::text <> ?
+		)
+		WHERE assignees IS NOT NULL AND assignees::jsonb @> ?::jsonb
+	`, strconv.FormatUint(uint64(user.ID), 10), fmt.Sprintf("[%d]", user.ID)).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Where("(workspace_id IS NULL AND user_id = ?) OR workspace_id IN ?", user.ID, append(orphaned, 0)).Delete(&models.Project{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Project{}).Where("owner_id = ?", user.ID).Update("owner_id", nil).Error; err != nil {
+		return err
+	}
+
+	if len(orphaned) > 0 {
+		if err := tx.Unscoped().Where("sprint_id IN (?)", tx.Model(&models.Sprint{}).Select("id").Where("workspace_id IN ?", orphaned)).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("workspace_id IN ?", orphaned).Delete(&models.Sprint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("workspace_id IN ?", orphaned).Delete(&models.Member{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", orphaned).Delete(&models.Workspace{}).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Member{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ? OR workspace_id IN ?", user.ID, append(orphaned, 0)).Delete(&models.GuestAccess{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.TeamMember{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Team{}).Where("lead_id = ?", user.ID).Update("lead_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ? OR task_id IN ?", user.ID, taskIds).Delete(&models.SentReminder{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Digest{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.DailyActivity{}).Error; err != nil {
+		return err
+	}
+	webhooks := tx.Model(&models.Webhook{}).Select("id").Where("(workspace_id IS NULL AND user_id = ?) OR workspace_id IN ?", user.ID, append(orphaned, 0))
+	if err := tx.Unscoped().Where("webhook_id IN (?)", webhooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("(workspace_id IS NULL AND user_id = ?) OR workspace_id IN ?", user.ID, append(orphaned, 0)).Delete(&models.Webhook{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Notification{}).Where("actor_id = ?", user.ID).Update("actor_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.UserSettings{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.AuthSession{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.OIDCState{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.DataExport{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.JoinRequest{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&user).Updates(map[string]interface{}{
+		"first_name":          "Deleted",
+		"last_name":           "User",
+		"email":               fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+		"password":            "",
+		"active_task":         nil,
+		"job_title":           nil,
+		"time_zone":           nil,
+		"bio":                 "",
+		"avatar_url":          nil,
+		"company":             nil,
+		"password_changed_at": time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&user).Error
+}
+
+func DeleteAccount(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	var body struct {
+		Password string `json:"password"`
+		Confirm  string `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	// Passwordless accounts confirm by typing their email instead
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+			return
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(body.Confirm), user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please confirm with your email address"})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteUserData(tx, user)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account! Please try again later."})
+		return
+	}
+
+	c.SetCookie("Authorization", "", -1, "", "", true, true)
+	c.SetCookie(middleware.CSRFCookieName, "", -1, "", "", true, false)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
@@ -1,15 +1,16 @@
 package profile
 
 import (
+	"log"
 	"master-management-api/internal/db"
-	"master-management-api/internal/handlers/history"
+	"master-management-api/internal/events"
 	"master-management-api/internal/handlers/task"
 	"master-management-api/internal/models"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserResponse struct {
@@ -27,28 +28,52 @@ type UserResponse struct {
 	JobTitle   *string `json:"job_title"`
 }
 
+func sessionStopped(session models.TaskSession) events.SessionStopped {
+	return events.SessionStopped{
+		SessionId: session.ID,
+		UserId:    session.UserID,
+		TaskId:    session.TaskID,
+		StartTime: session.StartTime,
+		EndTime:   *session.EndTime,
+		Duration:  session.Duration,
+	}
+}
+
 func StartTaskSession(userID uint, taskID uint) error {
-	var activeSession models.TaskSession
-	if err := db.DB.Where("end_time IS NULL").First(&activeSession).Error; err == nil {
-		now := time.Now()
-		activeSession.EndTime = &now
-		activeSession.Duration = int64(now.Sub(activeSession.StartTime).Seconds())
-		if err := db.DB.Save(&activeSession).Error; err != nil {
-			return err
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var activeSession models.TaskSession
+		if err := tx.Where("user_id = ? AND end_time IS NULL", userID).First(&activeSession).Error; err == nil {
+			now := time.Now()
+			activeSession.EndTime = &now
+			activeSession.Duration = int64(now.Sub(activeSession.StartTime).Seconds())
+			if err := tx.Save(&activeSession).Error; err != nil {
+				return err
+			}
+			if err := outbox.Record(tx, sessionStopped(activeSession)); err != nil {
+				return err
+			}
 		}
-	}
 
-	session := models.TaskSession{
-		TaskID:    taskID,
-		UserID:    userID,
-		StartTime: time.Now(),
+		session := models.TaskSession{
+			TaskID:    taskID,
+			UserID:    userID,
+			StartTime: time.Now(),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.SessionStarted{UserId: userID, TaskId: taskID})
+	}); err != nil {
+		return err
 	}
-	return db.DB.Create(&session).Error
+	outbox.Flush()
+	return nil
 }
 
-func StopTaskSession() error {
+func StopTaskSession(userID uint) error {
 	var session models.TaskSession
-	if err := db.DB.Where("end_time IS NULL").First(&session).Error; err != nil {
+	if err := db.DB.Where("user_id = ? AND end_time IS NULL", userID).First(&session).Error; err != nil {
 		return err
 	}
 
@@ -61,7 +86,17 @@ func StopTaskSession() error {
 	// 	Where("id = ?", session.TaskID).
 	// 	Update("time_spend", gorm.Expr("time_spend + ?", session.Duration))
 
-	return db.DB.Save(&session).Error
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, sessionStopped(session))
+	}); err != nil {
+		return err
+	}
+	outbox.Flush()
+	return nil
 }
 
 func GetProfile(c *gin.Context) {
@@ -181,20 +216,28 @@ func updateStartedAt(taskId uint, userId uint, c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found!"})
 		return
 	}
+	before := currTask
 	if currTask.StartedAt == nil {
 		currTask.StartedAt = &currentTime
-		history.LogHistory("started", "", "", currTask.ID, userId)
-		task.UpdateStreak(&currTask, true)
+		task.ApplyStreak(&currTask, true)
 	} else {
 		lastSessionTime := uint(currentTime.Sub(*currTask.StartedAt).Seconds())
-		totalTimeSpend := currTask.TimeSpend + lastSessionTime
-
-		history.LogHistory("stopped", strconv.FormatUint(uint64(currTask.TimeSpend), 10), strconv.FormatUint(uint64(totalTimeSpend), 10), currTask.ID, userId)
-
 		currTask.StartedAt = nil
-		currTask.TimeSpend = totalTimeSpend
-		task.UpdateStreak(&currTask, false)
+		currTask.TimeSpend += lastSessionTime
+		task.ApplyStreak(&currTask, false)
+	}
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&currTask).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskUpdated{Before: before, After: currTask, ActorId: userId})
+	}); err != nil {
+		log.Printf("Failed to update task %d: %v", currTask.ID, err)
+		return
 	}
+	outbox.Flush()
 }
 
 func UpdateActiveTask(c *gin.Context) {
@@ -224,7 +267,7 @@ func UpdateActiveTask(c *gin.Context) {
 	} else {
 		updateStartedAt(*user.ActiveTask, user.ID, c)
 		user.ActiveTask = nil
-		if err := StopTaskSession(); err != nil {
+		if err := StopTaskSession(user.ID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log stop session! " + err.Error()})
 			return
 		}
@@ -0,0 +1,386 @@
+package project
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var validStatuses = map[string]bool{
+	"planned":   true,
+	"active":    true,
+	"on_hold":   true,
+	"completed": true,
+}
+
+type projectWithProgress struct {
+	models.Project
+	TotalTasks     int64   `json:"total_tasks"`
+	CompletedTasks int64   `json:"completed_tasks"`
+	Progress       float64 `json:"progress"`
+}
+
+// Progress counts completed top level tasks and goals as 100 and the rest at their
+// own progress, so partly done goals move the project forward too.
+const progressColumns = `
+	(SELECT COUNT(*) FROM tasks t WHERE t.project_id = projects.id AND t.parent_id IS NULL AND t.deleted_at IS NULL) AS total_tasks,
+	(SELECT COUNT(*) FROM tasks t WHERE t.project_id = projects.id AND t.parent_id IS NULL AND t.deleted_at IS NULL AND t.status = 'completed') AS completed_tasks,
+	COALESCE((
+		SELECT AVG(CASE WHEN t.status = 'completed' THEN 100 ELSE LEAST(COALESCE(t.progress, 0), 100) END)
+		FROM tasks t WHERE t.project_id = projects.id AND t.parent_id IS NULL AND t.deleted_at IS NULL
+	), 0) AS progress
+`
+
+// scope limits project queries to the workspace in the URL, or to the caller's
+// personal projects on the /projects routes. Guests only see projects shared with them.
+func scope(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if workspaceId := c.Param("workspaceId"); workspaceId != "" {
+		query = query.Where("projects.workspace_id = ?", workspaceId)
+
+		memberData, _ := c.Get("member")
+		member := memberData.(models.Member)
+		if !permissions.Can(member.Role, permissions.ViewAllTasks) {
+			query = query.Where("projects.id IN (?)", permissions.GuestProjectIds(member.WorkspaceId, member.UserId))
+		}
+		return query
+	}
+
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+	return query.Where("projects.workspace_id IS NULL AND projects.user_id = ?", userId)
+}
+
+func findProject(c *gin.Context) (models.Project, bool) {
+	var project models.Project
+	if err := scope(c, db.DB.Where("projects.id = ?", c.Param("projectId"))).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return project, false
+	}
+	return project, true
+}
+
+func parseDate(value *string) (*time.Time, bool) {
+	if value == nil || *value == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.DateOnly, *value)
+	if err != nil {
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// Validate checks that a task may be attached to the project: personal tasks need a
+// personal project of the same user, workspace tasks a project of that workspace,
+// and archived projects take no new work. A zero ID detaches the task.
+func Validate(c *gin.Context, projectId uint, workspaceId *uint, userId uint) (*uint, bool) {
+	if projectId == 0 {
+		return nil, true
+	}
+
+	query := db.DB.Where("id = ?", projectId)
+	if workspaceId != nil {
+		query = query.Where("workspace_id = ?", *workspaceId)
+	} else {
+		query = query.Where("workspace_id IS NULL AND user_id = ?", userId)
+	}
+
+	var project models.Project
+	if err := query.First(&project).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		return nil, false
+	}
+	if project.ArchivedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot add tasks to an archived project"})
+		return nil, false
+	}
+
+	return &project.ID, true
+}
+
+func GetProjects(c *gin.Context) {
+	query := scope(c, db.DB.Table("projects").Select("projects.*, "+progressColumns)).
+		Where("projects.deleted_at IS NULL")
+
+	if c.Query("archived") != "true" {
+		query = query.Where("projects.archived_at IS NULL")
+	}
+	if status := c.QueryArray("status"); len(status) > 0 {
+		query = query.Where("projects.status IN ?", status)
+	}
+	if searchKey := c.Query("searchKey"); searchKey != "" {
+		query = query.Where("LOWER(projects.name) LIKE LOWER(?)", "%"+searchKey+"%")
+	}
+
+	var projects []projectWithProgress
+	if err := query.Order("projects.due_date ASC NULLS LAST, projects.created_at DESC").Scan(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve projects!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func GetProject(c *gin.Context) {
+	var project projectWithProgress
+	if err := scope(c, db.DB.Table("projects").Select("projects.*, "+progressColumns)).
+		Where("projects.id = ? AND projects.deleted_at IS NULL", c.Param("projectId")).
+		Scan(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project!"})
+		return
+	}
+	if project.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var tasks []models.Task
+	if err := db.DB.Where("project_id = ? AND parent_id IS NULL", project.ID).Order("created_at ASC").Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project tasks!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"project": project,
+			"tasks":   tasks,
+		},
+	})
+}
+
+func CreateProject(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var body struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Status      string  `json:"status"`
+		Color       string  `json:"color"`
+		OwnerId     *uint   `json:"owner_id"`
+		StartDate   *string `json:"start_date"`
+		DueDate     *string `json:"due_date"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+		return
+	}
+	if body.Status == "" {
+		body.Status = "active"
+	}
+	if !validStatuses[body.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	startDate, ok := parseDate(body.StartDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format."})
+		return
+	}
+	dueDate, ok := parseDate(body.DueDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format."})
+		return
+	}
+	if startDate != nil && dueDate != nil && dueDate.Before(*startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Due date cannot be before the start date"})
+		return
+	}
+
+	project := models.Project{
+		UserId:      userId,
+		OwnerId:     &userId,
+		Name:        name,
+		Description: body.Description,
+		Status:      body.Status,
+		Color:       body.Color,
+		StartDate:   startDate,
+		DueDate:     dueDate,
+	}
+
+	if workspaceIdParam := c.Param("workspaceId"); workspaceIdParam != "" {
+		workspaceId64, err := strconv.ParseUint(workspaceIdParam, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace id"})
+			return
+		}
+		workspaceId := uint(workspaceId64)
+		project.WorkspaceId = &workspaceId
+
+		if body.OwnerId != nil {
+			if ok, _ := permissions.AreMembers(workspaceId, []uint{*body.OwnerId}); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "The project owner must be a member of the workspace"})
+				return
+			}
+			project.OwnerId = body.OwnerId
+		}
+	}
+
+	if err := db.DB.Create(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project!"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully.",
+		"data":    project,
+	})
+}
+
+func UpdateProject(c *gin.Context) {
+	var body struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Status      *string `json:"status"`
+		Color       *string `json:"color"`
+		OwnerId     *uint   `json:"owner_id"`
+		StartDate   *string `json:"start_date"`
+		DueDate     *string `json:"due_date"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+			return
+		}
+		project.Name = name
+	}
+	if body.Description != nil {
+		project.Description = *body.Description
+	}
+	if body.Status != nil {
+		if !validStatuses[*body.Status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		project.Status = *body.Status
+	}
+	if body.Color != nil {
+		project.Color = *body.Color
+	}
+	if body.OwnerId != nil {
+		if project.WorkspaceId == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Personal projects cannot change owner"})
+			return
+		}
+		if ok, _ := permissions.AreMembers(*project.WorkspaceId, []uint{*body.OwnerId}); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The project owner must be a member of the workspace"})
+			return
+		}
+		project.OwnerId = body.OwnerId
+	}
+	if body.StartDate != nil {
+		startDate, ok := parseDate(body.StartDate)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format."})
+			return
+		}
+		project.StartDate = startDate
+	}
+	if body.DueDate != nil {
+		dueDate, ok := parseDate(body.DueDate)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format."})
+			return
+		}
+		project.DueDate = dueDate
+	}
+	if project.StartDate != nil && project.DueDate != nil && project.DueDate.Before(*project.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Due date cannot be before the start date"})
+		return
+	}
+
+	if err := db.DB.Save(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully.",
+		"data":    project,
+	})
+}
+
+func ArchiveProject(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	if project.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project is already archived"})
+		return
+	}
+
+	if err := db.DB.Model(&project).Update("archived_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive project!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project archived."})
+}
+
+func UnarchiveProject(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	if project.ArchivedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project is not archived"})
+		return
+	}
+
+	if err := db.DB.Model(&project).Update("archived_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore project!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project restored."})
+}
+
+// DeleteProject removes the project but keeps its tasks, which simply lose their project.
+func DeleteProject(c *gin.Context) {
+	project, ok := findProject(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("project_id = ?", project.ID).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully."})
+}
@@ -0,0 +1,175 @@
+package realtime
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/middleware"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/realtime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	heartbeatInterval = 25 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The socket is authenticated with the session cookie, so other sites must not be
+	// able to open it. Clients without an Origin header are not browsers.
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || middleware.AllowedOrigin(origin)
+	},
+}
+
+// topics resolves what the connection subscribes to: the user's own topic, plus every
+// workspace in ?workspace_id= (all of them when omitted) whose tasks the user may see.
+// Guests only receive events on their own topic.
+func topics(c *gin.Context, userId uint) ([]string, bool) {
+	requested := map[uint]bool{}
+	for _, value := range c.QueryArray("workspace_id") {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace id"})
+			return nil, false
+		}
+		requested[uint(id)] = true
+	}
+
+	var members []models.Member
+	if err := db.DB.Joins("JOIN workspaces ON workspaces.id = members.workspace_id AND workspaces.deleted_at IS NULL").
+		Where("members.user_id = ?", userId).
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspaces!"})
+		return nil, false
+	}
+
+	result := []string{realtime.UserTopic(userId)}
+	for _, member := range members {
+		if len(requested) > 0 && !requested[member.WorkspaceId] {
+			continue
+		}
+		if !permissions.Can(member.Role, permissions.ViewAllTasks) {
+			continue
+		}
+		result = append(result, realtime.WorkspaceTopic(member.WorkspaceId))
+		delete(requested, member.WorkspaceId)
+	}
+
+	if len(requested) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot follow one of the requested workspaces"})
+		return nil, false
+	}
+	return result, true
+}
+
+// Stream serves events as Server-Sent Events.
+func Stream(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	subscribed, ok := topics(c, userId)
+	if !ok {
+		return
+	}
+
+	subscriber := realtime.Subscribe(userId, subscribed)
+	defer realtime.Unsubscribe(subscriber)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteString("retry: 3000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case event, open := <-subscriber.Events:
+			if !open {
+				return
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// Socket serves the same events over a WebSocket. The connection only sends; anything
+// the client writes is read and discarded so close frames and pongs are handled.
+func Socket(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	subscribed, ok := topics(c, userId)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already wrote the error response
+		return
+	}
+	defer conn.Close()
+
+	subscriber := realtime.Subscribe(userId, subscribed)
+	defer realtime.Unsubscribe(subscriber)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * heartbeatInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * heartbeatInterval))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case event, open := <-subscriber.Events:
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "connection fell behind"),
+					time.Now().Add(writeTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"master-management-api/internal/db"
 	"master-management-api/internal/models"
+	"master-management-api/internal/notifications"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +26,10 @@ func CreateUserSettings(userId uint) error {
 		LongBreakAfter:    4,
 		GoalDuration:      30,
 		WeeklyTargetHours: 5,
+		TaskReminder:      true,
+		GoalProgress:      true,
+		Milestone:         true,
+		EmailReminders:    true,
 	}
 
 	if err := db.DB.Create(&settings).Error; err != nil {
@@ -70,12 +75,18 @@ func UpdateUserSettings(c *gin.Context) {
 		GoalProgress          *bool   `json:"goal_progress"`
 		SessionBreaks         *bool   `json:"session_breaks"`
 		DailySummary          *bool   `json:"daily_summary"`
+		WeeklySummary         *bool   `json:"weekly_summary"`
 		Milestone             *bool   `json:"milestone"`
 		NewFeature            *bool   `json:"new_feature"`
+		ReminderOffsets       *[]int  `json:"reminder_offsets"`
+		QuietHoursStart       *string `json:"quiet_hours_start"`
+		QuietHoursEnd         *string `json:"quiet_hours_end"`
+		EmailReminders        *bool   `json:"email_reminders"`
 		CloudSync             *bool   `json:"cloud_sync"`
 		KeepCompletedFor      *string `json:"keep_completed_for"`
 		AnalyticDataRetention *string `json:"analytic_data_retention"`
 		AutoDeleteOldData     *bool   `json:"auto_delete_old_data"`
+		HideFromTeamAnalytics *bool   `json:"hide_from_team_analytics"`
 		DebugMode             *bool   `json:"debug_mode"`
 		BetaFeatures          *bool   `json:"beta_features"`
 		Telemetry             *bool   `json:"telemetry"`
@@ -149,6 +160,9 @@ func UpdateUserSettings(c *gin.Context) {
 	if body.DailySummary != nil {
 		settings.DailySummary = *body.DailySummary
 	}
+	if body.WeeklySummary != nil {
+		settings.WeeklySummary = *body.WeeklySummary
+	}
 	if body.Milestone != nil {
 		settings.Milestone = *body.Milestone
 	}
@@ -167,6 +181,9 @@ func UpdateUserSettings(c *gin.Context) {
 	if body.AutoDeleteOldData != nil {
 		settings.AutoDeleteOldData = *body.AutoDeleteOldData
 	}
+	if body.HideFromTeamAnalytics != nil {
+		settings.HideFromTeamAnalytics = *body.HideFromTeamAnalytics
+	}
 	if body.DebugMode != nil {
 		settings.DebugMode = *body.DebugMode
 	}
@@ -185,6 +202,30 @@ func UpdateUserSettings(c *gin.Context) {
 	if body.TeamCollaboration != nil {
 		settings.TeamCollaboration = *body.TeamCollaboration
 	}
+	if body.ReminderOffsets != nil {
+		if !notifications.ValidOffsets(*body.ReminderOffsets) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Reminder offsets must be up to 5 values between 0 and 43200 minutes"})
+			return
+		}
+		settings.ReminderOffsets = body.ReminderOffsets
+	}
+	if body.QuietHoursStart != nil || body.QuietHoursEnd != nil {
+		start, end := settings.QuietHoursStart, settings.QuietHoursEnd
+		if body.QuietHoursStart != nil {
+			start = *body.QuietHoursStart
+		}
+		if body.QuietHoursEnd != nil {
+			end = *body.QuietHoursEnd
+		}
+		if !notifications.ValidQuietHours(start, end) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quiet hours must both be set as HH:MM, or both be empty"})
+			return
+		}
+		settings.QuietHoursStart, settings.QuietHoursEnd = start, end
+	}
+	if body.EmailReminders != nil {
+		settings.EmailReminders = *body.EmailReminders
+	}
 
 	body.UserId = &userId
 
@@ -219,6 +260,10 @@ func ResetSettings(c *gin.Context) {
 	settings.LongBreakAfter = 4
 	settings.GoalDuration = 30
 	settings.WeeklyTargetHours = 5
+	settings.TaskReminder = true
+	settings.GoalProgress = true
+	settings.Milestone = true
+	settings.EmailReminders = true
 
 	if err := db.DB.Save(&settings).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user settings!"})
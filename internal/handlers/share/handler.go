@@ -0,0 +1,283 @@
+package share
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const maxShareDays = 365
+
+type shareLinkResponse struct {
+	models.ShareLink
+	HasPassword bool   `json:"has_password"`
+	URL         string `json:"url,omitempty"`
+}
+
+func shareURL(token string) string {
+	base := os.Getenv("SHARE_URL")
+	if base == "" {
+		return "/share/" + token
+	}
+
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// findShareableTask loads a task the user may share: their own personal tasks, or
+// workspace tasks they are allowed to edit.
+func findShareableTask(c *gin.Context) (models.Task, bool) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var task models.Task
+	if err := db.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return task, false
+	}
+
+	if task.WorkspaceId == nil {
+		if task.UserId != userId {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return task, false
+		}
+		return task, true
+	}
+
+	member, err := permissions.GetMember(*task.WorkspaceId, userId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return task, false
+	}
+	if !permissions.Can(member.Role, permissions.EditTasks) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return task, false
+	}
+	return task, true
+}
+
+func CreateShareLink(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var body struct {
+		Password  string `json:"password"`
+		ExpiresIn *int   `json:"expires_in_days"` // nil never expires
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	task, ok := findShareableTask(c)
+	if !ok {
+		return
+	}
+
+	link := models.ShareLink{
+		TaskId: task.ID,
+		UserId: userId,
+	}
+
+	if body.ExpiresIn != nil {
+		if *body.ExpiresIn < 1 || *body.ExpiresIn > maxShareDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Links can expire in 1 to 365 days"})
+			return
+		}
+		expiresAt := time.Now().AddDate(0, 0, *body.ExpiresIn)
+		link.ExpiresAt = &expiresAt
+	}
+
+	if body.Password != "" {
+		// bcrypt ignores anything past 72 bytes
+		if len(body.Password) < 4 || len(body.Password) > 72 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be between 4 and 72 characters long"})
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link!"})
+			return
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link!"})
+		return
+	}
+	link.Token = token
+
+	if err := db.DB.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link!"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Share link created.",
+		"data": shareLinkResponse{
+			ShareLink:   link,
+			HasPassword: link.PasswordHash != "",
+			URL:         shareURL(link.Token),
+		},
+	})
+}
+
+func GetShareLinks(c *gin.Context) {
+	task, ok := findShareableTask(c)
+	if !ok {
+		return
+	}
+
+	var links []models.ShareLink
+	if err := db.DB.Where("task_id = ? AND revoked_at IS NULL", task.ID).Order("created_at DESC").Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve share links!"})
+		return
+	}
+
+	data := make([]shareLinkResponse, 0, len(links))
+	for _, link := range links {
+		data = append(data, shareLinkResponse{
+			ShareLink:   link,
+			HasPassword: link.PasswordHash != "",
+			URL:         shareURL(link.Token),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+func RevokeShareLink(c *gin.Context) {
+	task, ok := findShareableTask(c)
+	if !ok {
+		return
+	}
+
+	var link models.ShareLink
+	if err := db.DB.Where("id = ? AND task_id = ? AND revoked_at IS NULL", c.Param("linkId"), task.ID).First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+
+	if err := db.DB.Model(&link).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked."})
+}
+
+// GetSharedTask serves the read-only view of a shared task or goal. It sits outside
+// RequireAuth, so only fields that are safe to show to anyone are returned: no
+// assignees, notes, history or workspace details. Password protected links take the
+// password in the JSON body of a POST.
+func GetSharedTask(c *gin.Context) {
+	var link models.ShareLink
+	if err := db.DB.Where("token = ?", c.Param("token")).First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This link is invalid"})
+		return
+	}
+	if link.RevokedAt != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "This link has been revoked"})
+		return
+	}
+	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "This link has expired"})
+		return
+	}
+
+	if link.PasswordHash != "" {
+		var body struct {
+			Password string `json:"password"`
+		}
+		c.ShouldBindJSON(&body)
+		if body.Password == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "This link is password protected", "password_required": true})
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(body.Password)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password", "password_required": true})
+			return
+		}
+	}
+
+	var task models.Task
+	if err := db.DB.First(&task, link.TaskId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "The shared item no longer exists"})
+		return
+	}
+	if task.WorkspaceId != nil {
+		var count int64
+		db.DB.Model(&models.Workspace{}).Where("id = ?", *task.WorkspaceId).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "The shared item no longer exists"})
+			return
+		}
+
+		// The link lives only as long as its creator may still share the task
+		member, err := permissions.GetMember(*task.WorkspaceId, link.UserId)
+		if err != nil || !permissions.Can(member.Role, permissions.EditTasks) {
+			c.JSON(http.StatusGone, gin.H{"error": "This link has been revoked"})
+			return
+		}
+	}
+
+	type SharedChecklist struct {
+		Title     string `json:"title"`
+		Completed bool   `json:"completed"`
+	}
+	type SharedSubtask struct {
+		Title    string   `json:"title"`
+		Status   string   `json:"status"`
+		Progress *float64 `json:"progress"`
+	}
+
+	checklists := []SharedChecklist{}
+	if err := db.DB.Model(&models.Checklist{}).Select("title, completed").Where("task_id = ?", task.ID).Order("id ASC").Scan(&checklists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist"})
+		return
+	}
+
+	subtasks := []SharedSubtask{}
+	if err := db.DB.Model(&models.Task{}).Select("title, status, progress").Where("parent_id = ?", task.ID).Order("id ASC").Scan(&subtasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subtasks"})
+		return
+	}
+
+	db.DB.Model(&link).Updates(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": time.Now(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"title":           task.Title,
+			"description":     task.Description,
+			"type":            task.Type,
+			"status":          task.Status,
+			"progress":        task.Progress,
+			"target_value":    task.TargetValue,
+			"target_type":     task.TargetType,
+			"target_progress": task.TargetProgress,
+			"due_date":        task.DueDate,
+			"completed_at":    task.CompletedAt,
+			"checklists":      checklists,
+			"subtasks":        subtasks,
+		},
+	})
+}
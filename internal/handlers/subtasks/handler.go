@@ -2,7 +2,7 @@ package subtasks
 
 import (
 	"master-management-api/internal/db"
-	"master-management-api/internal/handlers/history"
+	"master-management-api/internal/events"
 	"master-management-api/internal/models"
 	"master-management-api/internal/utils"
 	"net/http"
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetAllSubtasks(c *gin.Context) {
@@ -115,23 +116,27 @@ func SaveSubtasks(c *gin.Context) {
 		})
 	}
 
-	if err := db.DB.Create(&subtasks).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&subtasks).Error; err != nil {
+			return err
+		}
+		for _, task := range subtasks {
+			if err := outbox.Record(tx, events.TaskCreated{Task: task, ActorId: userId}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subtasks"})
 		return
 	}
+	outbox.Flush()
 
-	for _, task := range subtasks {
-		history.LogHistory("created", "", task.Title, task.ID, userId)
-	}
-
-	var taskProgress float64
-	if body[0].ParentId != nil {
-		progress, err := utils.RecalculateProgress(*body[0].ParentId)
-		taskProgress = progress
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
-			return
-		}
+	taskProgress, err := utils.Progress(parentId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
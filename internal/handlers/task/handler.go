@@ -3,16 +3,19 @@ package task
 import (
 	"fmt"
 	"master-management-api/internal/db"
-	"master-management-api/internal/handlers/history"
+	"master-management-api/internal/events"
+	"master-management-api/internal/handlers/project"
 	"master-management-api/internal/models"
+	"master-management-api/internal/notifications"
+	"master-management-api/internal/permissions"
 	"master-management-api/internal/utils"
 	"net/http"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TaskResponseType struct {
@@ -32,9 +35,18 @@ type TaskResponseType struct {
 	TargetFrequency *string    `json:"target_frequency"`
 	ParentProgress  *float64   `json:"parent_progress"`
 	TargetProgress  *float64   `json:"target_progress"`
+	ProjectId       *uint      `json:"project_id"`
 }
 
 func UpdateStreak(task *models.Task, saveStartTime bool) uint {
+	ApplyStreak(task, saveStartTime)
+	db.DB.Save(task)
+
+	return task.Streak
+}
+
+// ApplyStreak is UpdateStreak for callers that save the task themselves.
+func ApplyStreak(task *models.Task, saveStartTime bool) {
 	currentTime := time.Now()
 
 	if task.LastStartedAt != nil {
@@ -58,10 +70,6 @@ func UpdateStreak(task *models.Task, saveStartTime bool) uint {
 		}
 		task.LastStartedAt = &currentTime
 	}
-
-	db.DB.Save(task)
-
-	return task.Streak
 }
 
 func GetAllTasks(c *gin.Context) {
@@ -91,6 +99,9 @@ func GetAllTasks(c *gin.Context) {
 	if taskType == "task" || taskType == "goal" {
 		query = query.Where("type = ?", taskType)
 	}
+	if project := c.Query("project"); project != "" {
+		query = query.Where("project_id = ?", project)
+	}
 
 	if searchKey != "" {
 		likeQuery := "%" + searchKey + "%"
@@ -193,6 +204,7 @@ func GetAllTasks(c *gin.Context) {
 			TargetType:      task.TargetType,
 			TargetFrequency: task.TargetFrequency,
 			TargetProgress:  task.TargetProgress,
+			ProjectId:       task.ProjectId,
 		})
 	}
 
@@ -208,11 +220,13 @@ func CreateTask(c *gin.Context) {
 		ParentId        *uint    `json:"parent_id"` // Optional parent ID for subtasks
 		Type            string   `json:"type"`
 		WorkspaceId     *uint    `json:"workspace_id"`
+		ProjectId       uint     `json:"project_id"`
 		TargetValue     *float64 `json:"target_value"`
 		TargetType      *string  `json:"target_type"`
 		TargetFrequency *string  `json:"target_frequency"`
 		TargetProgress  *float64 `json:"target_progress"`
 		DueDate         *string  `json:"due_date"`
+		ReminderOffsets *[]int   `json:"reminder_offsets"` // Minutes before the due date, overrides the user's defaults
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -230,9 +244,31 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
+	if body.ReminderOffsets != nil && !notifications.ValidOffsets(*body.ReminderOffsets) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Up to 5 reminders can be set, each at most 30 days before the due date"})
+		return
+	}
+
 	userDataRaw, _ := c.Get("user")
 	userId := userDataRaw.(models.User).ID
 
+	if body.WorkspaceId != nil {
+		member, err := permissions.GetMember(*body.WorkspaceId, userId)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this workspace"})
+			return
+		}
+		if !permissions.Can(member.Role, permissions.CreateTasks) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+			return
+		}
+	}
+
+	projectId, ok := project.Validate(c, body.ProjectId, body.WorkspaceId, userId)
+	if !ok {
+		return
+	}
+
 	task := models.Task{
 		UserId:          userId,
 		Title:           body.Title,
@@ -242,10 +278,12 @@ func CreateTask(c *gin.Context) {
 		ParentId:        body.ParentId, // Set parent ID if provided
 		Type:            body.Type,
 		WorkspaceId:     body.WorkspaceId,
+		ProjectId:       projectId,
 		TargetValue:     body.TargetValue,
 		TargetType:      body.TargetType,
 		TargetFrequency: body.TargetFrequency,
 		TargetProgress:  body.TargetProgress,
+		ReminderOffsets: body.ReminderOffsets,
 	}
 	if body.DueDate != nil && *body.DueDate != "" {
 		parsedDate, err := time.Parse(time.DateOnly, *body.DueDate)
@@ -256,28 +294,30 @@ func CreateTask(c *gin.Context) {
 		task.DueDate = &parsedDate
 	}
 
-	result := db.DB.Create(&task)
-
-	if result.Error != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskCreated{Task: task, ActorId: userId})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create task",
 		})
 		return
 	}
+	outbox.Flush()
 
 	var parentProgress float64
 	if body.ParentId != nil {
-		progress, err := utils.RecalculateProgress(*body.ParentId)
+		progress, err := utils.Progress(*body.ParentId)
 		parentProgress = progress
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 			return
 		}
-		history.LogHistory("subtask", "", body.Title, *body.ParentId, userId)
 	}
 
-	history.LogHistory("created", "", body.Title, task.ID, userId)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Task Created successfully.",
 		"data": TaskResponseType{
@@ -291,6 +331,7 @@ func CreateTask(c *gin.Context) {
 			DueDate:        task.DueDate,
 			Category:       task.Category,
 			ParentProgress: &parentProgress,
+			ProjectId:      task.ProjectId,
 		},
 	})
 }
@@ -306,7 +347,13 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Delete(&task).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskDeleted{Task: task, ActorId: userId})
+	}); err != nil {
 		if task.ParentId != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sub task"})
 			return
@@ -314,9 +361,10 @@ func DeleteTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
 		return
 	}
+	outbox.Flush()
 
 	if task.ParentId != nil {
-		progress, err := utils.RecalculateProgress(*task.ParentId)
+		progress, err := utils.Progress(*task.ParentId)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 			return
@@ -384,6 +432,7 @@ func GetTask(c *gin.Context) {
 			"target_progress":  task.TargetProgress,
 			"due_date":         task.DueDate,
 			"category":         task.Category,
+			"project_id":       task.ProjectId,
 		},
 	})
 }
@@ -409,6 +458,8 @@ func UpdateTask(c *gin.Context) {
 		TargetProgress  *float64  `json:"target_progress"`
 		DueDate         *string   `json:"due_date"`
 		Category        *string   `json:"category"`
+		ProjectId       *uint     `json:"project_id"` // 0 removes the project
+		ReminderOffsets *[]int    `json:"reminder_offsets"`
 	}
 
 	if err := c.Bind(&body); err != nil {
@@ -421,10 +472,10 @@ func UpdateTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
+	before := task
 
 	// Update fields
 	if body.Title != nil {
-		history.LogHistory("title_update", task.Title, *body.Title, task.ID, userId)
 		task.Title = *body.Title
 	}
 	if body.Status != nil {
@@ -432,33 +483,46 @@ func UpdateTask(c *gin.Context) {
 			currentTime := time.Now()
 			task.CompletedAt = &currentTime
 		}
-		history.LogHistory("status_update", task.Status, *body.Status, task.ID, userId)
 		task.Status = *body.Status
 	}
 	if body.TimeSpend != nil {
-		history.LogHistory("stopped", strconv.FormatUint(uint64(task.TimeSpend), 10), strconv.FormatUint(uint64(*body.TimeSpend), 10), task.ID, userId)
 		task.TimeSpend = *body.TimeSpend
 	}
 	if body.Streak != nil {
 		task.Streak = *body.Streak
 	}
 	if body.Description != nil {
-		history.LogHistory("description_update", task.Description, *body.Description, task.ID, userId)
 		task.Description = *body.Description
 	}
 	if body.Priority != nil {
-		if task.Priority != nil && *task.Priority != "" {
-			history.LogHistory("priority_change", *task.Priority, *body.Priority, task.ID, userId)
-		} else {
-			history.LogHistory("priority_change", "", *body.Priority, task.ID, userId)
-		}
 		task.Priority = body.Priority
 	}
 	if body.Tags != nil {
 		task.Tags = body.Tags
 	}
 	if body.Assignees != nil {
-		task.Assignees = body.Assignees
+		if task.WorkspaceId == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only workspace tasks can have assignees"})
+			return
+		}
+		assignees := utils.Unique(*body.Assignees)
+		ok, err := permissions.AreMembers(*task.WorkspaceId, assignees)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate assignees!"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Assignees must be members of the workspace"})
+			return
+		}
+		task.Assignees = &assignees
+	}
+	if body.ProjectId != nil && (task.ProjectId == nil || *task.ProjectId != *body.ProjectId) {
+		projectId, ok := project.Validate(c, *body.ProjectId, task.WorkspaceId, userId)
+		if !ok {
+			return
+		}
+		task.ProjectId = projectId
 	}
 	if body.TargetValue != nil {
 		task.TargetValue = body.TargetValue
@@ -493,6 +557,13 @@ func UpdateTask(c *gin.Context) {
 			task.DueDate = &parsedDue
 		}
 	}
+	if body.ReminderOffsets != nil {
+		if !notifications.ValidOffsets(*body.ReminderOffsets) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Up to 5 reminders can be set, each at most 30 days before the due date"})
+			return
+		}
+		task.ReminderOffsets = body.ReminderOffsets
+	}
 
 	// Handle StartedAt
 	if body.StartedAt != nil {
@@ -504,20 +575,26 @@ func UpdateTask(c *gin.Context) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid started_at format. Use ISO 8601"})
 				return
 			}
-			history.LogHistory("started", "", "", task.ID, userId)
 			task.StartedAt = &parsedTime
 
 			UpdateStreak(&task, true)
 		}
 	}
 
-	if err := db.DB.Save(&task).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskUpdated{Before: before, After: task, ActorId: userId})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
+	outbox.Flush()
 
 	if body.Status != nil && task.ParentId != nil {
-		progress, err := utils.RecalculateProgress(*task.ParentId)
+		progress, err := utils.Progress(*task.ParentId)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 			return
@@ -529,7 +606,7 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 	if body.TargetProgress != nil || body.TargetValue != nil {
-		progress, err := utils.RecalculateProgress(task.ID)
+		progress, err := utils.Progress(task.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
 			return
@@ -750,3 +827,34 @@ func GetCategories(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
+
+func GetAssignedTasks(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	status := c.QueryArray("status")
+
+	type AssignedTask struct {
+		models.Task
+		WorkspaceName string `json:"workspace_name"`
+	}
+
+	// Only tasks in workspaces the user still belongs to
+	query := db.DB.Table("tasks").
+		Select("tasks.*, workspaces.name as workspace_name").
+		Joins("JOIN workspaces ON workspaces.id = tasks.workspace_id AND workspaces.deleted_at IS NULL").
+		Joins("JOIN members ON members.workspace_id = tasks.workspace_id AND members.user_id = ? AND members.deleted_at IS NULL", userId).
+		Where("tasks.deleted_at IS NULL AND tasks.assignees IS NOT NULL AND tasks.assignees::jsonb @> ?::jsonb", fmt.Sprintf("[%d]", userId))
+
+	if len(status) > 0 && !utils.Contains(status, "all") {
+		query = query.Where("tasks.status IN ?", status)
+	}
+
+	var tasks []AssignedTask
+	if err := query.Order("tasks.due_date ASC NULLS LAST, tasks.created_at DESC").Scan(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve assigned tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tasks})
+}
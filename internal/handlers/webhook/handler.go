@@ -0,0 +1,323 @@
+package webhook
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/webhooks"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxWebhooks     = 10
+	defaultPageSize = 30
+	maxPageSize     = 100
+)
+
+// scope limits webhook queries to the workspace in the URL, or to the caller's personal
+// webhooks on the /webhooks routes.
+func scope(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if workspaceId := c.Param("workspaceId"); workspaceId != "" {
+		return query.Where("webhooks.workspace_id = ?", workspaceId)
+	}
+
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+	return query.Where("webhooks.workspace_id IS NULL AND webhooks.user_id = ?", userId)
+}
+
+func findWebhook(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+	if err := scope(c, db.DB.Where("webhooks.id = ?", c.Param("webhookId"))).First(&hook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return hook, false
+	}
+	return hook, true
+}
+
+func validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return false
+	}
+	return parsed.Scheme == "https" || parsed.Scheme == "http"
+}
+
+// GetWebhookEvents lists the event types webhooks can subscribe to.
+func GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": webhooks.Events})
+}
+
+func GetWebhooks(c *gin.Context) {
+	hooks := []models.Webhook{}
+	if err := scope(c, db.DB).Order("created_at DESC").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhooks!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": hooks})
+}
+
+// CreateWebhook registers a webhook. The signing secret is only returned here and
+// when it is rotated.
+func CreateWebhook(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var body struct {
+		URL         string   `json:"url"`
+		Description string   `json:"description"`
+		Events      []string `json:"events"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	body.URL = strings.TrimSpace(body.URL)
+	if !validURL(body.URL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL must be a valid http or https address"})
+		return
+	}
+	if !webhooks.ValidEvents(body.Events) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Choose at least one valid event"})
+		return
+	}
+
+	var count int64
+	scope(c, db.DB.Model(&models.Webhook{})).Count(&count)
+	if count >= maxWebhooks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can have at most 10 webhooks"})
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook!"})
+		return
+	}
+
+	hook := models.Webhook{
+		UserId:      userId,
+		URL:         body.URL,
+		Description: strings.TrimSpace(body.Description),
+		Secret:      secret,
+		Events:      &body.Events,
+		Active:      true,
+	}
+	if c.Param("workspaceId") != "" {
+		memberData, _ := c.Get("member")
+		workspaceId := memberData.(models.Member).WorkspaceId
+		hook.WorkspaceId = &workspaceId
+	}
+
+	if err := db.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook!"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created.",
+		"data":    hook,
+		"secret":  secret,
+	})
+}
+
+func UpdateWebhook(c *gin.Context) {
+	var body struct {
+		URL         *string   `json:"url"`
+		Description *string   `json:"description"`
+		Events      *[]string `json:"events"`
+		Active      *bool     `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if body.URL != nil {
+		value := strings.TrimSpace(*body.URL)
+		if !validURL(value) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "URL must be a valid http or https address"})
+			return
+		}
+		hook.URL = value
+	}
+	if body.Description != nil {
+		hook.Description = strings.TrimSpace(*body.Description)
+	}
+	if body.Events != nil {
+		if !webhooks.ValidEvents(*body.Events) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Choose at least one valid event"})
+			return
+		}
+		hook.Events = body.Events
+	}
+	if body.Active != nil {
+		hook.Active = *body.Active
+		// Turning a webhook back on gives it a fresh start after it was disabled
+		if hook.Active {
+			hook.DisabledAt = nil
+			hook.FailureCount = 0
+		}
+	}
+
+	if err := db.DB.Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated.",
+		"data":    hook,
+	})
+}
+
+func DeleteWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&hook).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted."})
+}
+
+func RotateWebhookSecret(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate secret!"})
+		return
+	}
+	if err := db.DB.Model(&hook).Update("secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate secret!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Secret rotated. Deliveries are signed with the new secret from now on.",
+		"secret":  secret,
+	})
+}
+
+// GetWebhookDeliveries returns the delivery log newest first. Older pages are fetched
+// with ?before=<id of the last delivery>.
+func GetWebhookDeliveries(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageSize)
+	}
+
+	query := db.DB.Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if before := c.Query("before"); before != "" {
+		if _, err := strconv.ParseUint(before, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries!"})
+		return
+	}
+
+	var nextCursor *uint
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		nextCursor = &deliveries[limit-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        deliveries,
+		"next_cursor": nextCursor,
+	})
+}
+
+// RedeliverWebhook queues a logged delivery to be sent again with the same payload.
+func RedeliverWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	if err := db.DB.Where("id = ? AND webhook_id = ?", c.Param("deliveryId"), hook.ID).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if delivery.Status == "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This delivery is still being retried"})
+		return
+	}
+
+	if err := db.DB.Model(&delivery).Updates(map[string]interface{}{
+		"status":          "pending",
+		"attempts":        0,
+		"next_attempt_at": gorm.Expr("NOW()"),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue delivery!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery queued."})
+}
+
+// PingWebhook sends a test event right away and returns how the receiver answered.
+func PingWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := webhooks.SendPing(hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send ping!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ping sent.",
+		"data":    delivery,
+	})
+}
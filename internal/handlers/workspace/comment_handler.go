@@ -0,0 +1,435 @@
+package workspace
+
+import (
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/handlers/history"
+	"master-management-api/internal/jobs"
+	"master-management-api/internal/models"
+	"master-management-api/internal/notifications"
+	"master-management-api/internal/permissions"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCommentLength = 5000
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w][\w.\-]*)`)
+
+type commentWithAuthor struct {
+	models.Comment
+	AuthorName string               `json:"author_name"`
+	Replies    []*commentWithAuthor `json:"replies" gorm:"-"`
+}
+
+// summarize shortens comment content for history entries and emails.
+func summarize(content string) string {
+	runes := []rune(strings.TrimSpace(content))
+	if len(runes) > 140 {
+		return string(runes[:140]) + "…"
+	}
+	return string(runes)
+}
+
+// resolveMentions maps the @handles in content to workspace members. A handle matches
+// the local part of a member's email, "first.last", or a first name shared by nobody else.
+func resolveMentions(workspaceId uint, content string) ([]uint, error) {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	var members []struct {
+		UserId    uint
+		FirstName string
+		LastName  string
+		Email     string
+	}
+	if err := db.DB.Table("members").
+		Select("members.user_id, users.first_name, users.last_name, users.email").
+		Joins("JOIN users ON users.id = members.user_id").
+		Where("members.workspace_id = ? AND members.deleted_at IS NULL", workspaceId).
+		Scan(&members).Error; err != nil {
+		return nil, err
+	}
+
+	handles := map[string][]uint{}
+	add := func(handle string, userId uint) {
+		handle = strings.ToLower(handle)
+		if handle == "" {
+			return
+		}
+		for _, id := range handles[handle] {
+			if id == userId {
+				return
+			}
+		}
+		handles[handle] = append(handles[handle], userId)
+	}
+	for _, member := range members {
+		if at := strings.Index(member.Email, "@"); at > 0 {
+			add(member.Email[:at], member.UserId)
+		}
+		first := strings.ReplaceAll(strings.TrimSpace(member.FirstName), " ", "")
+		last := strings.ReplaceAll(strings.TrimSpace(member.LastName), " ", "")
+		if first != "" && last != "" {
+			add(first+"."+last, member.UserId)
+		}
+		add(first, member.UserId)
+	}
+
+	seen := map[uint]bool{}
+	userIds := []uint{}
+	for _, match := range matches {
+		// Trailing punctuation is part of the sentence, not the handle
+		handle := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		candidates := handles[handle]
+		if len(candidates) != 1 || seen[candidates[0]] {
+			continue
+		}
+		seen[candidates[0]] = true
+		userIds = append(userIds, candidates[0])
+	}
+
+	return userIds, nil
+}
+
+// canSeeTask reports whether a mentioned member may see the task. Guests are only
+// told about tasks that were shared with them.
+func canSeeTask(task models.Task, userId uint) bool {
+	member, err := permissions.GetMember(*task.WorkspaceId, userId)
+	if err != nil {
+		return false
+	}
+	if permissions.Can(member.Role, permissions.ViewAllTasks) {
+		return true
+	}
+
+	var count int64
+	permissions.GuestTaskIds(member.WorkspaceId, userId).Where("id = ?", task.ID).Count(&count)
+	return count > 0
+}
+
+// recordMentions stores a mention for every newly mentioned member other than the
+// author and emails them.
+func recordMentions(comment models.Comment, task models.Task, author models.User, alreadyMentioned []uint) {
+	userIds, err := resolveMentions(*task.WorkspaceId, comment.Content)
+	if err != nil {
+		log.Printf("Failed to resolve mentions: %v", err)
+		return
+	}
+
+	skip := map[uint]bool{author.ID: true}
+	for _, id := range alreadyMentioned {
+		skip[id] = true
+	}
+
+	authorName := strings.TrimSpace(author.FirstName + " " + author.LastName)
+	for _, userId := range userIds {
+		if skip[userId] || !canSeeTask(task, userId) {
+			continue
+		}
+
+		mention := models.Mention{
+			CommentId:   comment.ID,
+			TaskId:      task.ID,
+			WorkspaceId: *task.WorkspaceId,
+			UserId:      userId,
+			MentionedBy: author.ID,
+		}
+		if err := db.DB.Create(&mention).Error; err != nil {
+			log.Printf("Failed to record mention: %v", err)
+			continue
+		}
+		// Email follows the in-app notification, so users who turned mentions or
+		// email off are not emailed either
+		if !notifications.Mentioned(task, userId, author.ID, summarize(comment.Content)) || !notifications.EmailEnabled(userId) {
+			continue
+		}
+
+		var mentioned models.User
+		if err := db.DB.First(&mentioned, userId).Error; err != nil {
+			continue
+		}
+		if err := jobs.Enqueue(db.DB, jobs.SendEmail{
+			To:      mentioned.Email,
+			Subject: fmt.Sprintf("%s mentioned you on \"%s\"", authorName, task.Title),
+			Text:    fmt.Sprintf("%s mentioned you in a comment on \"%s\":\n\n%s\n", authorName, task.Title, summarize(comment.Content)),
+		}); err != nil {
+			log.Printf("Failed to queue mention email: %v", err)
+		}
+	}
+}
+
+func GetComments(c *gin.Context) {
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	var comments []*commentWithAuthor
+	if err := db.DB.Table("comments").
+		Select("comments.*, CONCAT(users.first_name, ' ', users.last_name) as author_name").
+		Joins("JOIN users ON users.id = comments.user_id").
+		Where("comments.task_id = ? AND comments.deleted_at IS NULL", task.ID).
+		Order("comments.created_at ASC").
+		Scan(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments!"})
+		return
+	}
+
+	byId := map[uint]*commentWithAuthor{}
+	for _, comment := range comments {
+		comment.Replies = []*commentWithAuthor{}
+		byId[comment.ID] = comment
+	}
+
+	threads := []*commentWithAuthor{}
+	for _, comment := range comments {
+		if comment.ParentId != nil {
+			if parent, ok := byId[*comment.ParentId]; ok {
+				parent.Replies = append(parent.Replies, comment)
+				continue
+			}
+		}
+		threads = append(threads, comment)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": threads})
+}
+
+func CreateComment(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	var body struct {
+		Content  string `json:"content"`
+		ParentId *uint  `json:"parent_id"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	content := strings.TrimSpace(body.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+	if len(content) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Comments can be at most %d characters", maxCommentLength)})
+		return
+	}
+
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	if body.ParentId != nil {
+		var parent models.Comment
+		if err := db.DB.Where("id = ? AND task_id = ?", *body.ParentId, task.ID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found"})
+			return
+		}
+	}
+
+	comment := models.Comment{
+		TaskId:   task.ID,
+		UserId:   user.ID,
+		ParentId: body.ParentId,
+		Content:  content,
+	}
+	if err := db.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment!"})
+		return
+	}
+
+	history.LogHistory("comment", "", summarize(content), task.ID, user.ID)
+	recordMentions(comment, task, user, nil)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment added.",
+		"data":    comment,
+	})
+}
+
+func UpdateComment(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	var body struct {
+		Content string `json:"content"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	content := strings.TrimSpace(body.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+	if len(content) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Comments can be at most %d characters", maxCommentLength)})
+		return
+	}
+
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	if err := db.DB.Where("id = ? AND task_id = ?", c.Param("commentId"), task.ID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	if comment.UserId != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own comments"})
+		return
+	}
+
+	var alreadyMentioned []uint
+	db.DB.Model(&models.Mention{}).Where("comment_id = ?", comment.ID).Pluck("user_id", &alreadyMentioned)
+
+	before := comment.Content
+	now := time.Now()
+	comment.Content = content
+	comment.EditedAt = &now
+
+	if err := db.DB.Save(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment!"})
+		return
+	}
+
+	history.LogHistory("comment_edited", summarize(before), summarize(content), task.ID, user.ID)
+	recordMentions(comment, task, user, alreadyMentioned)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated.",
+		"data":    comment,
+	})
+}
+
+func DeleteComment(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	if err := db.DB.Where("id = ? AND task_id = ?", c.Param("commentId"), task.ID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	// Authors remove their own comments, moderators can remove anyone's
+	if comment.UserId != userId && !permissions.Can(member.Role, permissions.DeleteTasks) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var commentIds []uint
+		if err := tx.Raw(`
+			WITH RECURSIVE thread AS (
+				SELECT id FROM comments WHERE id = ?
+				UNION
+				SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+			)
+			SELECT id FROM thread
+		`, comment.ID).Scan(&commentIds).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("comment_id IN ?", commentIds).Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", commentIds).Delete(&models.Comment{}).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment!"})
+		return
+	}
+
+	history.LogHistory("comment_deleted", summarize(comment.Content), "", task.ID, userId)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted."})
+}
+
+func GetMyMentions(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	type MentionWithContext struct {
+		models.Mention
+		Content         string `json:"content"`
+		TaskTitle       string `json:"task_title"`
+		WorkspaceName   string `json:"workspace_name"`
+		MentionedByName string `json:"mentioned_by_name"`
+	}
+
+	query := db.DB.Table("mentions").
+		Select(`mentions.*, comments.content, tasks.title as task_title, workspaces.name as workspace_name,
+			CONCAT(users.first_name, ' ', users.last_name) as mentioned_by_name`).
+		Joins("JOIN comments ON comments.id = mentions.comment_id AND comments.deleted_at IS NULL").
+		Joins("JOIN tasks ON tasks.id = mentions.task_id AND tasks.deleted_at IS NULL").
+		Joins("JOIN workspaces ON workspaces.id = mentions.workspace_id AND workspaces.deleted_at IS NULL").
+		Joins("JOIN members ON members.workspace_id = mentions.workspace_id AND members.user_id = mentions.user_id AND members.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = mentions.mentioned_by").
+		Where("mentions.user_id = ? AND mentions.deleted_at IS NULL", userId)
+
+	if c.Query("unread") == "true" {
+		query = query.Where("mentions.read_at IS NULL")
+	}
+
+	var mentions []MentionWithContext
+	if err := query.Order("mentions.created_at DESC").Limit(100).Scan(&mentions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve mentions!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": mentions})
+}
+
+func MarkMentionsRead(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var body struct {
+		Ids []uint `json:"ids"` // empty marks everything as read
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	query := db.DB.Model(&models.Mention{}).Where("user_id = ? AND read_at IS NULL", userId)
+	if len(body.Ids) > 0 {
+		query = query.Where("id IN ?", body.Ids)
+	}
+
+	if err := query.Update("read_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mentions!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mentions marked as read."})
+}
@@ -0,0 +1,163 @@
+package workspace
+
+import (
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type guestAccessWithTitle struct {
+	models.GuestAccess
+	TaskTitle   *string `json:"task_title"`
+	ProjectName *string `json:"project_name"`
+}
+
+// findGuest loads the guest membership named by :userId.
+func findGuest(c *gin.Context) (models.Member, bool) {
+	var member models.Member
+	if err := db.DB.Where("workspace_id = ? AND user_id = ?", c.Param("workspaceId"), c.Param("userId")).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return member, false
+	}
+	if member.Role != permissions.RoleGuest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access can only be granted to guests, other members already see every task"})
+		return member, false
+	}
+	return member, true
+}
+
+// removeGuestAccess drops everything that was shared with a departing member.
+func removeGuestAccess(workspaceId interface{}, userId uint) {
+	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).Delete(&models.GuestAccess{}).Error; err != nil {
+		log.Printf("Failed to remove guest access of user %d: %v", userId, err)
+	}
+}
+
+func GetGuestAccess(c *gin.Context) {
+	guest, ok := findGuest(c)
+	if !ok {
+		return
+	}
+
+	access := []guestAccessWithTitle{}
+	if err := db.DB.Table("guest_accesses").
+		Select("guest_accesses.*, tasks.title AS task_title, projects.name AS project_name").
+		Joins("LEFT JOIN tasks ON tasks.id = guest_accesses.task_id AND tasks.deleted_at IS NULL").
+		Joins("LEFT JOIN projects ON projects.id = guest_accesses.project_id AND projects.deleted_at IS NULL").
+		Where("guest_accesses.workspace_id = ? AND guest_accesses.user_id = ? AND guest_accesses.deleted_at IS NULL", guest.WorkspaceId, guest.UserId).
+		Order("guest_accesses.created_at DESC").
+		Scan(&access).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guest access!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": access})
+}
+
+func GrantGuestAccess(c *gin.Context) {
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	var body struct {
+		TaskIds    []uint `json:"task_ids"`
+		ProjectIds []uint `json:"project_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	taskIds := utils.Unique(body.TaskIds)
+	projectIds := utils.Unique(body.ProjectIds)
+	if len(taskIds) == 0 && len(projectIds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Select at least one task or project to share"})
+		return
+	}
+
+	guest, ok := findGuest(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	db.DB.Model(&models.Task{}).Where("id IN ? AND workspace_id = ?", append(taskIds, 0), guest.WorkspaceId).Count(&count)
+	if int(count) != len(taskIds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tasks must belong to this workspace"})
+		return
+	}
+	db.DB.Model(&models.Project{}).Where("id IN ? AND workspace_id = ?", append(projectIds, 0), guest.WorkspaceId).Count(&count)
+	if int(count) != len(projectIds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Projects must belong to this workspace"})
+		return
+	}
+
+	var existing []models.GuestAccess
+	db.DB.Where("workspace_id = ? AND user_id = ?", guest.WorkspaceId, guest.UserId).Find(&existing)
+	sharedTasks, sharedProjects := map[uint]bool{}, map[uint]bool{}
+	for _, access := range existing {
+		if access.TaskId != nil {
+			sharedTasks[*access.TaskId] = true
+		}
+		if access.ProjectId != nil {
+			sharedProjects[*access.ProjectId] = true
+		}
+	}
+
+	added := 0
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, taskId := range taskIds {
+			if sharedTasks[taskId] {
+				continue
+			}
+			if err := tx.Create(&models.GuestAccess{WorkspaceId: guest.WorkspaceId, UserId: guest.UserId, TaskId: &taskId, GrantedBy: member.UserId}).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		for _, projectId := range projectIds {
+			if sharedProjects[projectId] {
+				continue
+			}
+			if err := tx.Create(&models.GuestAccess{WorkspaceId: guest.WorkspaceId, UserId: guest.UserId, ProjectId: &projectId, GrantedBy: member.UserId}).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant access!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Access granted.",
+		"added":   added,
+	})
+}
+
+func RevokeGuestAccess(c *gin.Context) {
+	guest, ok := findGuest(c)
+	if !ok {
+		return
+	}
+
+	var access models.GuestAccess
+	if err := db.DB.Where("id = ? AND workspace_id = ? AND user_id = ?", c.Param("accessId"), guest.WorkspaceId, guest.UserId).First(&access).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Access not found"})
+		return
+	}
+
+	if err := db.DB.Delete(&access).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked."})
+}
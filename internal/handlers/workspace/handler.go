@@ -2,12 +2,16 @@ package workspace
 
 import (
 	"master-management-api/internal/db"
+	"master-management-api/internal/events"
 	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
 	"master-management-api/internal/utils"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ResponseType struct {
@@ -30,11 +34,15 @@ type ResponseType struct {
 	Achievements    *[]string  `json:"achievements" gorm:"serializer:json"`
 	WorkspaceId     *uint      `json:"workspace_id"`
 	Assignees       *[]uint    `json:"assignees" gorm:"serializer:json"`
+	TeamId          *uint      `json:"team_id"`
+	ProjectId       *uint      `json:"project_id"`
 	CompletedAt     *time.Time `json:"completed_at"`
 	Progress        *float64   `json:"progress"`
 	TargetValue     *float64   `json:"target_value"`
 	TargetType      *string    `json:"target_type"`
 	TargetFrequency *string    `json:"target_frequency"`
+	EstimatedHours  *float64   `json:"estimated_hours"`
+	StoryPoints     *float64   `json:"story_points"`
 	SubTaskCount    *int64     `json:"sub_task_count"`
 }
 
@@ -49,7 +57,7 @@ func GetWorkspaces(c *gin.Context) {
 		Table("workspaces").
 		Select("workspaces.*").
 		Joins("JOIN members wm ON wm.workspace_id = workspaces.id").
-		Where("wm.user_id = ? AND wm.deleted_at IS NULL", userId)
+		Where("wm.user_id = ? AND wm.deleted_at IS NULL AND workspaces.deleted_at IS NULL", userId)
 
 	if searchKey != "" {
 		query = query.Where("LOWER(name) LIKE ?", "%"+searchKey+"%")
@@ -68,6 +76,8 @@ func GetWorkspaces(c *gin.Context) {
 			"manager_id": workspace.ManagerId,
 			"created_at": workspace.CreatedAt,
 			"type":       workspace.Type,
+
+			"deletion_scheduled_at": workspace.DeletionScheduledAt,
 		})
 	}
 
@@ -75,21 +85,22 @@ func GetWorkspaces(c *gin.Context) {
 }
 
 func GetWorkspaceById(c *gin.Context) {
-	userData, _ := c.Get("user")
-	userId := userData.(models.User).ID
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
 
 	workspaceId := c.Param("workspaceId")
 
 	var workspace models.Workspace
-	if err := db.DB.Table("workspaces").
-		Select("workspaces.*").
-		Joins("JOIN members ON members.workspace_id = workspaces.id").
-		Where("members.user_id = ? AND workspaces.id = ? AND members.deleted_at IS NULL", userId, workspaceId).
-		First(&workspace).Error; err != nil {
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workspace details!"})
 		return
 	}
 
+	if !permissions.Can(member.Role, permissions.ManageInvites) {
+		workspace.InviteCode = ""
+		workspace.InviteCodeExpiresAt = nil
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": workspace})
 }
 
@@ -106,12 +117,13 @@ func CreateWorkspace(c *gin.Context) {
 		return
 	}
 
-	inviteCode := utils.GenerateInviteCode(4)
+	inviteCodeExpiresAt := time.Now().Add(inviteCodeTTL)
 
 	workspace := models.Workspace{
-		Name:       body.Name,
-		ManagerId:  userId,
-		InviteCode: inviteCode,
+		Name:                body.Name,
+		ManagerId:           userId,
+		InviteCode:          utils.GenerateInviteCode(inviteCodeLength),
+		InviteCodeExpiresAt: &inviteCodeExpiresAt,
 	}
 
 	if err := db.DB.Create(&workspace).Error; err != nil {
@@ -124,7 +136,7 @@ func CreateWorkspace(c *gin.Context) {
 	member := models.Member{
 		WorkspaceId: workspace.ID,
 		UserId:      userId,
-		Role:        "manager",
+		Role:        permissions.RoleManager,
 		JoinedAt:    &currentTime,
 	}
 
@@ -153,52 +165,28 @@ func JoinWorkspace(c *gin.Context) {
 	}
 
 	var workspace models.Workspace
-	if err := db.DB.Where("invite_code = ?", body.InviteCode).First(&workspace).Error; err != nil {
+	if err := db.DB.Where("invite_code = ?", strings.TrimSpace(body.InviteCode)).First(&workspace).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite code"})
 		return
 	}
 
-	var existing models.Member
-	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspace.ID, userId).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Already a member"})
+	// Codes issued before expiry was tracked are treated as expired
+	if workspace.InviteCodeExpiresAt == nil || time.Now().After(*workspace.InviteCodeExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invite code has expired. Ask a workspace admin for a new one"})
 		return
 	}
 
-	currentTime := time.Now()
-
-	member := models.Member{
-		WorkspaceId: workspace.ID,
-		UserId:      userId,
-		Role:        "member",
-		JoinedAt:    &currentTime,
-	}
-
-	if err := db.DB.Create(&member).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join workspace! Please try again later."})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Joined successfully.",
-		"workspace": gin.H{
-			"id":   workspace.ID,
-			"name": workspace.Name,
-		},
-	})
+	joinOrRequest(c, workspace, userId, permissions.RoleMember, nil, workspace.RequireApproval)
 }
 
 func LeaveWorkspace(c *gin.Context) {
 	userData, _ := c.Get("user")
 	userId := userData.(models.User).ID
 
-	workspaceId := c.Param("workspaceId")
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
 
-	// Check if workspace exists and user is a member
-	var member models.Member
-	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&member).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of this workspace"})
-		return
-	}
+	workspaceId := c.Param("workspaceId")
 
 	// Check if user is not the manager
 	var workspace models.Workspace
@@ -213,19 +201,30 @@ func LeaveWorkspace(c *gin.Context) {
 	}
 
 	// Delete the member record
-	if err := db.DB.Delete(&member).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.MemberRemoved{Member: member, ActorId: userId})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave workspace"})
 		return
 	}
+	removeFromTeams(workspaceId, userId)
+	removeGuestAccess(workspaceId, userId)
+	outbox.Flush()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully left the workspace"})
 }
 
 func GetMembers(c *gin.Context) {
-	userData, _ := c.Get("user")
-	userId := userData.(models.User).ID
-	if userId == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized!"})
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	// Guests work with a handful of shared tasks and must not learn who else is in the workspace
+	if member.Role == permissions.RoleGuest {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Guests cannot view the member list"})
 		return
 	}
 
@@ -250,20 +249,18 @@ func GetMembers(c *gin.Context) {
 }
 
 func GetWorkspaceTasks(c *gin.Context) {
-	userData, _ := c.Get("user")
-	userId := userData.(models.User).ID
-
 	workspaceId := c.Param("workspaceId")
 	searchKey := c.Query("searchKey")
 
-	if userId == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	var tasks []models.Task
-	query := db.DB.Where("workspace_id = ? AND type = 'task'", workspaceId)
+	query := visibleTasks(c, db.DB.Where("workspace_id = ? AND type = 'task'", workspaceId))
 
+	if team := c.Query("team"); team != "" {
+		query = query.Where("team_id = ?", team)
+	}
+	if project := c.Query("project"); project != "" {
+		query = query.Where("project_id = ?", project)
+	}
 	if searchKey != "" {
 		query = query.Where("LOWER(title) LIKE ?", "%"+searchKey+"%")
 	}
@@ -302,11 +299,15 @@ func GetWorkspaceTasks(c *gin.Context) {
 			Achievements:    task.Achievements,
 			WorkspaceId:     task.WorkspaceId,
 			Assignees:       task.Assignees,
+			TeamId:          task.TeamId,
+			ProjectId:       task.ProjectId,
 			CompletedAt:     task.CompletedAt,
 			Progress:        task.Progress,
 			TargetValue:     task.TargetValue,
 			TargetType:      task.TargetType,
 			TargetFrequency: task.TargetFrequency,
+			EstimatedHours:  task.EstimatedHours,
+			StoryPoints:     task.StoryPoints,
 			SubTaskCount:    &subtaskCount,
 		})
 	}
@@ -315,20 +316,18 @@ func GetWorkspaceTasks(c *gin.Context) {
 }
 
 func GetWorkspaceGoals(c *gin.Context) {
-	userData, _ := c.Get("user")
-	userId := userData.(models.User).ID
-
 	workspaceId := c.Param("workspaceId")
 	searchKey := c.Query("searchKey")
 
-	if userId == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	var goals []models.Task
-	query := db.DB.Where("workspace_id = ? AND type = 'goal'", workspaceId)
+	query := visibleTasks(c, db.DB.Where("workspace_id = ? AND type = 'goal'", workspaceId))
 
+	if team := c.Query("team"); team != "" {
+		query = query.Where("team_id = ?", team)
+	}
+	if project := c.Query("project"); project != "" {
+		query = query.Where("project_id = ?", project)
+	}
 	if searchKey != "" {
 		query = query.Where("LOWER(title) LIKE ?", "%"+searchKey+"%")
 	}
@@ -367,11 +366,15 @@ func GetWorkspaceGoals(c *gin.Context) {
 			Achievements:    goal.Achievements,
 			WorkspaceId:     goal.WorkspaceId,
 			Assignees:       goal.Assignees,
+			TeamId:          goal.TeamId,
+			ProjectId:       goal.ProjectId,
 			CompletedAt:     goal.CompletedAt,
 			Progress:        goal.Progress,
 			TargetValue:     goal.TargetValue,
 			TargetType:      goal.TargetType,
 			TargetFrequency: goal.TargetFrequency,
+			EstimatedHours:  goal.EstimatedHours,
+			StoryPoints:     goal.StoryPoints,
 			SubTaskCount:    &subGoalsCount,
 		})
 	}
@@ -380,9 +383,10 @@ func GetWorkspaceGoals(c *gin.Context) {
 }
 
 func UpdateMember(c *gin.Context) {
-	// userData, _ := c.Get("user")
-	// userId := userData.(models.User).ID
+	requesterData, _ := c.Get("member")
+	requester := requesterData.(models.Member)
 
+	workspaceId := c.Param("workspaceId")
 	memberId := c.Param("memberId")
 
 	var body struct {
@@ -397,12 +401,42 @@ func UpdateMember(c *gin.Context) {
 	}
 
 	var member models.Member
-	if err := db.DB.Where("id = ?", memberId).First(&member).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve member details!"})
+	if err := db.DB.Where("workspace_id = ? AND id = ?", workspaceId, memberId).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
 		return
 	}
 
-	if body.Role != nil {
+	// Members may edit their own appearance, anything else needs elevated rights
+	if (body.AvatarUrl != nil || body.ProfileColor != nil) && member.ID != requester.ID &&
+		!permissions.Can(requester.Role, permissions.ManageMembers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+
+	if body.Role != nil && *body.Role != member.Role {
+		if !permissions.Can(requester.Role, permissions.ChangeRoles) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+			return
+		}
+		if !permissions.ValidRole(*body.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+		if !permissions.Outranks(requester.Role, member.Role) || !permissions.CanGrant(requester.Role, *body.Role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change the role of members at or above your own, or grant roles above your own"})
+			return
+		}
+
+		var workspace models.Workspace
+		if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			return
+		}
+		if workspace.ManagerId == member.UserId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change the role of the workspace owner. Transfer ownership first"})
+			return
+		}
+
 		member.Role = *body.Role
 	}
 	if body.AvatarUrl != nil {
@@ -412,10 +446,17 @@ func UpdateMember(c *gin.Context) {
 		member.ProfileColor = *body.ProfileColor
 	}
 
-	if err := db.DB.Save(&member).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.MemberUpdated{Member: member, ActorId: requester.UserId})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update!"})
 		return
 	}
+	outbox.Flush()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Updated successfully."})
 }
@@ -424,33 +465,44 @@ func RemoveMember(c *gin.Context) {
 	userData, _ := c.Get("user")
 	userId := userData.(models.User).ID
 
+	requesterData, _ := c.Get("member")
+	requester := requesterData.(models.Member)
+
 	workspaceId := c.Param("workspaceId")
 	memberToRemoveId := c.Param("memberId")
 
-	// Check if requester is manager/admin
-	var requesterMember models.Member
-	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&requesterMember).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of this workspace"})
+	// Get member to remove
+	var memberToRemove models.Member
+	if err := db.DB.Where("workspace_id = ? AND id = ?", workspaceId, memberToRemoveId).First(&memberToRemove).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
 		return
 	}
 
-	if requesterMember.Role != "manager" && requesterMember.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only managers and admins can remove members"})
+	if memberToRemove.UserId == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use leave workspace to remove yourself"})
 		return
 	}
 
-	// Get member to remove
-	var memberToRemove models.Member
-	if err := db.DB.Where("workspace_id = ? AND id = ?", workspaceId, memberToRemoveId).First(&memberToRemove).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	if !permissions.Outranks(requester.Role, memberToRemove.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot remove a member with a role at or above your own"})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+	if workspace.ManagerId == memberToRemove.UserId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove the workspace owner. Transfer ownership first"})
 		return
 	}
 
 	// Check if removing a manager
-	if memberToRemove.Role == "manager" {
+	if memberToRemove.Role == permissions.RoleManager {
 		// Count remaining managers
 		var managerCount int64
-		db.DB.Model(&models.Member{}).Where("workspace_id = ? AND role = ? AND deleted_at IS NULL", workspaceId, "manager").Count(&managerCount)
+		db.DB.Model(&models.Member{}).Where("workspace_id = ? AND role = ? AND deleted_at IS NULL", workspaceId, permissions.RoleManager).Count(&managerCount)
 
 		if managerCount <= 1 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove the only manager. Assign another manager first"})
@@ -459,10 +511,19 @@ func RemoveMember(c *gin.Context) {
 	}
 
 	// Soft delete the member
-	if err := db.DB.Delete(&memberToRemove).Error; err != nil {
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&memberToRemove).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.MemberRemoved{Member: memberToRemove, ActorId: userId})
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
 		return
 	}
+	removeFromTeams(workspaceId, memberToRemove.UserId)
+	removeGuestAccess(workspaceId, memberToRemove.UserId)
+	outbox.Flush()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
 }
@@ -0,0 +1,590 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/events"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/utils"
+	"master-management-api/pkg/mailer"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	inviteCodeLength      = 10
+	inviteCodeTTL         = 7 * 24 * time.Hour
+	defaultInvitationDays = 7
+	maxInvitationDays     = 30
+)
+
+func invitationURL(token string) string {
+	base := os.Getenv("INVITE_URL")
+	if base == "" {
+		return "/invitations/" + token
+	}
+
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+func invitationExpiry(days uint) (time.Time, bool) {
+	if days == 0 {
+		days = defaultInvitationDays
+	}
+	if days > maxInvitationDays {
+		return time.Time{}, false
+	}
+	return time.Now().Add(time.Duration(days) * 24 * time.Hour), true
+}
+
+// validateInviteRole checks that the inviter may hand out the requested role.
+func validateInviteRole(c *gin.Context, inviter models.Member, role string) (string, bool) {
+	if role == "" {
+		role = permissions.RoleMember
+	}
+	if !permissions.ValidRole(role) || role == permissions.RoleManager {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return "", false
+	}
+	if !permissions.CanGrant(inviter.Role, role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot invite members with a role above your own"})
+		return "", false
+	}
+	return role, true
+}
+
+var errInvitationUsed = errors.New("invitation already used")
+
+// claimInvitationUse counts a use of the invitation as part of tx, so it is only used
+// up when the join or join request is stored. The conditions keep concurrent accepts
+// from exceeding max uses.
+func claimInvitationUse(tx *gorm.DB, invitationId *uint) error {
+	if invitationId == nil {
+		return nil
+	}
+
+	result := tx.Model(&models.WorkspaceInvitation{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", *invitationId, time.Now()).
+		Where("max_uses = 0 OR use_count < max_uses").
+		Update("use_count", gorm.Expr("use_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvitationUsed
+	}
+	return nil
+}
+
+// joinOrRequest adds the user to the workspace, or files a join request for a manager
+// to review when approval is required.
+func joinOrRequest(c *gin.Context, workspace models.Workspace, userId uint, role string, invitationId *uint, requireApproval bool) {
+	var existing models.Member
+	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspace.ID, userId).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already a member"})
+		return
+	}
+
+	if requireApproval {
+		var pending int64
+		db.DB.Model(&models.JoinRequest{}).
+			Where("workspace_id = ? AND user_id = ? AND status = 'pending'", workspace.ID, userId).
+			Count(&pending)
+		if pending > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Your request to join is already pending"})
+			return
+		}
+
+		request := models.JoinRequest{
+			WorkspaceId:  workspace.ID,
+			UserId:       userId,
+			Role:         role,
+			Status:       "pending",
+			InvitationId: invitationId,
+		}
+		if err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&request).Error; err != nil {
+				return err
+			}
+			return claimInvitationUse(tx, invitationId)
+		}); err != nil {
+			if errors.Is(err, errInvitationUsed) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "This invitation has already been used"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request to join! Please try again later."})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Request to join sent. A workspace admin needs to approve it.",
+			"workspace": gin.H{
+				"id":   workspace.ID,
+				"name": workspace.Name,
+			},
+		})
+		return
+	}
+
+	currentTime := time.Now()
+
+	member := models.Member{
+		WorkspaceId: workspace.ID,
+		UserId:      userId,
+		Role:        role,
+		JoinedAt:    &currentTime,
+	}
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		if err := claimInvitationUse(tx, invitationId); err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.MemberJoined{Member: member, ActorId: userId})
+	}); err != nil {
+		if errors.Is(err, errInvitationUsed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This invitation has already been used"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join workspace! Please try again later."})
+		return
+	}
+	outbox.Flush()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Joined successfully.",
+		"workspace": gin.H{
+			"id":   workspace.ID,
+			"name": workspace.Name,
+		},
+	})
+}
+
+func RotateInviteCode(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	expiresAt := time.Now().Add(inviteCodeTTL)
+	workspace.InviteCode = utils.GenerateInviteCode(inviteCodeLength)
+	workspace.InviteCodeExpiresAt = &expiresAt
+
+	if err := db.DB.Save(&workspace).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate invite code!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invite_code":            workspace.InviteCode,
+		"invite_code_expires_at": workspace.InviteCodeExpiresAt,
+	})
+}
+
+func CreateEmailInvitation(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	memberData, _ := c.Get("member")
+	inviter := memberData.(models.Member)
+
+	workspaceId := c.Param("workspaceId")
+
+	var body struct {
+		Email         string `json:"email" binding:"required"`
+		Role          string `json:"role"`
+		ExpiresInDays uint   `json:"expires_in_days"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if !strings.Contains(email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+
+	role, ok := validateInviteRole(c, inviter, body.Role)
+	if !ok {
+		return
+	}
+
+	expiresAt, ok := invitationExpiry(body.ExpiresInDays)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invitations can last at most %d days", maxInvitationDays)})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	var alreadyMember int64
+	db.DB.Table("members").
+		Joins("JOIN users ON users.id = members.user_id").
+		Where("members.workspace_id = ? AND LOWER(users.email) = ? AND members.deleted_at IS NULL", workspace.ID, email).
+		Count(&alreadyMember)
+	if alreadyMember > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This person is already a member"})
+		return
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation!"})
+		return
+	}
+
+	// A new invitation replaces any pending one for the same address
+	db.DB.Model(&models.WorkspaceInvitation{}).
+		Where("workspace_id = ? AND kind = 'email' AND email = ? AND revoked_at IS NULL AND use_count = 0", workspace.ID, email).
+		Update("revoked_at", time.Now())
+
+	invitation := models.WorkspaceInvitation{
+		WorkspaceId: workspace.ID,
+		Kind:        "email",
+		Email:       email,
+		Role:        role,
+		Token:       token,
+		InvitedBy:   user.ID,
+		ExpiresAt:   expiresAt,
+		MaxUses:     1,
+	}
+	if err := db.DB.Create(&invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation!"})
+		return
+	}
+
+	link := invitationURL(token)
+	inviterName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if err := mailer.Send(mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviterName, workspace.Name),
+		Text: fmt.Sprintf("%s invited you to join the workspace \"%s\" as %s.\n\nAccept the invitation: %s\n\nThis invitation expires on %s.\n",
+			inviterName, workspace.Name, role, link, expiresAt.Format("Jan 2, 2006")),
+	}); err != nil {
+		log.Printf("Failed to send invitation email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation sent.",
+		"data":    invitation,
+		"url":     link,
+	})
+}
+
+func CreateInviteLink(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	inviter := memberData.(models.Member)
+
+	workspaceId := c.Param("workspaceId")
+
+	var body struct {
+		Role          string `json:"role"`
+		MaxUses       uint   `json:"max_uses"`
+		ExpiresInDays uint   `json:"expires_in_days"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	role, ok := validateInviteRole(c, inviter, body.Role)
+	if !ok {
+		return
+	}
+
+	expiresAt, ok := invitationExpiry(body.ExpiresInDays)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invite links can last at most %d days", maxInvitationDays)})
+		return
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite link!"})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	invitation := models.WorkspaceInvitation{
+		WorkspaceId: workspace.ID,
+		Kind:        "link",
+		Role:        role,
+		Token:       token,
+		InvitedBy:   userId,
+		ExpiresAt:   expiresAt,
+		MaxUses:     body.MaxUses,
+	}
+	if err := db.DB.Create(&invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite link!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invite link created.",
+		"data":    invitation,
+		"url":     invitationURL(token),
+	})
+}
+
+func GetInvitations(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	var invitations []models.WorkspaceInvitation
+	if err := db.DB.
+		Where("workspace_id = ? AND revoked_at IS NULL AND expires_at > ?", workspaceId, time.Now()).
+		Where("max_uses = 0 OR use_count < max_uses").
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invitations!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func RevokeInvitation(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+	invitationId := c.Param("invitationId")
+
+	var invitation models.WorkspaceInvitation
+	if err := db.DB.Where("workspace_id = ? AND id = ?", workspaceId, invitationId).First(&invitation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	if invitation.RevokedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation already revoked."})
+		return
+	}
+
+	if err := db.DB.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invitation!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked."})
+}
+
+// findUsableInvitation resolves a token to an invitation that can still be accepted.
+func findUsableInvitation(token string) (models.WorkspaceInvitation, models.Workspace, string) {
+	var invitation models.WorkspaceInvitation
+	var workspace models.Workspace
+
+	if err := db.DB.Where("token = ?", token).First(&invitation).Error; err != nil {
+		return invitation, workspace, "Invalid invitation"
+	}
+	if invitation.RevokedAt != nil {
+		return invitation, workspace, "This invitation has been revoked"
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return invitation, workspace, "This invitation has expired"
+	}
+	if invitation.MaxUses > 0 && invitation.UseCount >= invitation.MaxUses {
+		return invitation, workspace, "This invitation has already been used"
+	}
+	if err := db.DB.First(&workspace, invitation.WorkspaceId).Error; err != nil {
+		return invitation, workspace, "Workspace no longer exists"
+	}
+
+	return invitation, workspace, ""
+}
+
+func GetInvitation(c *gin.Context) {
+	invitation, workspace, problem := findUsableInvitation(c.Param("token"))
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"kind":       invitation.Kind,
+			"email":      invitation.Email,
+			"role":       invitation.Role,
+			"expires_at": invitation.ExpiresAt,
+			"workspace": gin.H{
+				"id":   workspace.ID,
+				"name": workspace.Name,
+			},
+		},
+	})
+}
+
+func AcceptInvitation(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	invitation, workspace, problem := findUsableInvitation(c.Param("token"))
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+
+	if invitation.Kind == "email" && !strings.EqualFold(invitation.Email, user.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This invitation was sent to a different email address"})
+		return
+	}
+
+	var existing models.Member
+	if err := db.DB.Where("workspace_id = ? AND user_id = ?", workspace.ID, user.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already a member"})
+		return
+	}
+
+	// Personal invitations were approved when they were sent
+	requireApproval := workspace.RequireApproval && invitation.Kind == "link"
+	joinOrRequest(c, workspace, user.ID, invitation.Role, &invitation.ID, requireApproval)
+}
+
+func GetMyInvitations(c *gin.Context) {
+	userData, _ := c.Get("user")
+	user := userData.(models.User)
+
+	type InvitationWithWorkspace struct {
+		ID            uint      `json:"id"`
+		Role          string    `json:"role"`
+		ExpiresAt     time.Time `json:"expires_at"`
+		WorkspaceId   uint      `json:"workspace_id"`
+		WorkspaceName string    `json:"workspace_name"`
+		Token         string    `json:"token"`
+	}
+
+	var invitations []InvitationWithWorkspace
+	if err := db.DB.Table("workspace_invitations").
+		Select("workspace_invitations.id, workspace_invitations.role, workspace_invitations.expires_at, workspace_invitations.workspace_id, workspace_invitations.token, workspaces.name as workspace_name").
+		Joins("JOIN workspaces ON workspaces.id = workspace_invitations.workspace_id AND workspaces.deleted_at IS NULL").
+		Where("workspace_invitations.kind = 'email' AND workspace_invitations.email = ?", strings.ToLower(user.Email)).
+		Where("workspace_invitations.revoked_at IS NULL AND workspace_invitations.use_count = 0 AND workspace_invitations.expires_at > ?", time.Now()).
+		Where("workspace_invitations.deleted_at IS NULL").
+		Scan(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invitations!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func GetJoinRequests(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	type JoinRequestWithName struct {
+		models.JoinRequest
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	var requests []JoinRequestWithName
+	if err := db.DB.Table("join_requests").
+		Select("join_requests.*, CONCAT(users.first_name, ' ', users.last_name) as name, users.email").
+		Joins("JOIN users ON users.id = join_requests.user_id").
+		Where("join_requests.workspace_id = ? AND join_requests.status = 'pending' AND join_requests.deleted_at IS NULL", workspaceId).
+		Order("join_requests.created_at ASC").
+		Scan(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join requests!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+func ReviewJoinRequest(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	workspaceId := c.Param("workspaceId")
+	requestId := c.Param("requestId")
+
+	var body struct {
+		Approve bool `json:"approve"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	var request models.JoinRequest
+	if err := db.DB.Where("workspace_id = ? AND id = ? AND status = 'pending'", workspaceId, requestId).First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return
+	}
+
+	now := time.Now()
+	request.ReviewedBy = &userId
+	request.ReviewedAt = &now
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if !body.Approve {
+			request.Status = "rejected"
+			return tx.Save(&request).Error
+		}
+
+		request.Status = "approved"
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		tx.Model(&models.Member{}).Where("workspace_id = ? AND user_id = ?", request.WorkspaceId, request.UserId).Count(&existing)
+		if existing > 0 {
+			return nil
+		}
+
+		member := models.Member{
+			WorkspaceId: request.WorkspaceId,
+			UserId:      request.UserId,
+			Role:        request.Role,
+			JoinedAt:    &now,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.MemberJoined{Member: member, ActorId: userId})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review join request!"})
+		return
+	}
+	outbox.Flush()
+
+	if body.Approve {
+		c.JSON(http.StatusOK, gin.H{"message": "Join request approved."})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Join request rejected."})
+}
@@ -0,0 +1,239 @@
+package workspace
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func deletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("WORKSPACE_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func UpdateWorkspace(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	var body struct {
+		Name            *string `json:"name"`
+		Description     *string `json:"description"`
+		Type            *string `json:"type"`
+		RequireApproval *bool   `json:"require_approval"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+			return
+		}
+		workspace.Name = name
+	}
+	if body.Description != nil {
+		workspace.Description = *body.Description
+	}
+	if body.Type != nil {
+		workspace.Type = *body.Type
+	}
+	if body.RequireApproval != nil {
+		workspace.RequireApproval = *body.RequireApproval
+	}
+
+	if err := db.DB.Save(&workspace).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workspace!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Workspace updated successfully.",
+		"data":    workspace,
+	})
+}
+
+func TransferOwnership(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	workspaceId := c.Param("workspaceId")
+
+	var body struct {
+		MemberId uint `json:"member_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	if workspace.ManagerId != userId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the workspace owner can transfer ownership"})
+		return
+	}
+
+	var newOwner models.Member
+	if err := db.DB.Where("workspace_id = ? AND id = ?", workspaceId, body.MemberId).First(&newOwner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	if newOwner.UserId == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You already own this workspace"})
+		return
+	}
+	if newOwner.Role == permissions.RoleGuest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ownership cannot be transferred to a guest"})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&workspace).Update("manager_id", newOwner.UserId).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&newOwner).Update("role", permissions.RoleManager).Error; err != nil {
+			return err
+		}
+		// The previous owner stays on as an admin
+		return tx.Model(&models.Member{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Update("role", permissions.RoleAdmin).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer ownership!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ownership transferred successfully."})
+}
+
+func DeleteWorkspace(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	workspaceId := c.Param("workspaceId")
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	if workspace.ManagerId != userId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the workspace owner can delete the workspace"})
+		return
+	}
+
+	if workspace.DeletionScheduledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Workspace is already scheduled for deletion"})
+		return
+	}
+
+	scheduledAt := time.Now().Add(deletionGracePeriod())
+	if err := db.DB.Model(&workspace).Update("deletion_scheduled_at", scheduledAt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule workspace deletion!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Workspace scheduled for deletion.",
+		"deletion_scheduled_at": scheduledAt,
+	})
+}
+
+func RestoreWorkspace(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	workspaceId := c.Param("workspaceId")
+
+	var workspace models.Workspace
+	if err := db.DB.First(&workspace, workspaceId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	if workspace.ManagerId != userId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the workspace owner can restore the workspace"})
+		return
+	}
+
+	if workspace.DeletionScheduledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace is not scheduled for deletion"})
+		return
+	}
+
+	if err := db.DB.Model(&workspace).Update("deletion_scheduled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore workspace!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace restored successfully."})
+}
+
+// PurgeScheduledWorkspaces soft-deletes workspaces whose grace period has passed,
+// together with their tasks, goals, subtasks and memberships.
+func PurgeScheduledWorkspaces() error {
+	var workspaces []models.Workspace
+	if err := db.DB.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).Find(&workspaces).Error; err != nil {
+		return err
+	}
+
+	for _, workspace := range workspaces {
+		if err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var taskIds []uint
+			if err := tx.Raw(`
+				WITH RECURSIVE doomed AS (
+					SELECT id FROM tasks WHERE workspace_id = ? AND deleted_at IS NULL
+					UNION
+					SELECT t.id FROM tasks t JOIN doomed d ON t.parent_id = d.id WHERE t.deleted_at IS NULL
+				)
+				SELECT id FROM doomed
+			`, workspace.ID).Scan(&taskIds).Error; err != nil {
+				return err
+			}
+
+			if len(taskIds) > 0 {
+				if err := tx.Where("id IN ?", taskIds).Delete(&models.Task{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("workspace_id = ?", workspace.ID).Delete(&models.Member{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("workspace_id = ?", workspace.ID).Delete(&models.Webhook{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&workspace).Error
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
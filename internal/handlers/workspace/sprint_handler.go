@@ -0,0 +1,639 @@
+package workspace
+
+import (
+	"errors"
+	"io"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sprintUnits maps the ?unit= of the burndown to the weight of a single task.
+var sprintUnits = map[string]string{
+	"points": "COALESCE(t.story_points, 0)",
+	"hours":  "COALESCE(t.estimated_hours, 0)",
+	"tasks":  "1",
+}
+
+type sprintTaskWithDetails struct {
+	models.SprintTask
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	Priority       *string    `json:"priority"`
+	Assignees      *[]uint    `json:"assignees" gorm:"serializer:json"`
+	StoryPoints    *float64   `json:"story_points"`
+	EstimatedHours *float64   `json:"estimated_hours"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+func findSprint(c *gin.Context) (models.Sprint, bool) {
+	var sprint models.Sprint
+	if err := db.DB.Where("id = ? AND workspace_id = ?", c.Param("sprintId"), c.Param("workspaceId")).First(&sprint).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint not found"})
+		return sprint, false
+	}
+	return sprint, true
+}
+
+func parseSprintDate(value string) (time.Time, bool) {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// currentSprintTasks returns the tasks that are in the sprint right now.
+func currentSprintTasks(sprintId uint) ([]sprintTaskWithDetails, error) {
+	tasks := []sprintTaskWithDetails{}
+	err := db.DB.Table("sprint_tasks").
+		Select("sprint_tasks.*, t.title, t.status, t.priority, t.assignees, t.story_points, t.estimated_hours, t.completed_at").
+		Joins("JOIN tasks t ON t.id = sprint_tasks.task_id AND t.deleted_at IS NULL").
+		Where("sprint_tasks.sprint_id = ? AND sprint_tasks.removed_at IS NULL AND sprint_tasks.deleted_at IS NULL", sprintId).
+		Order("sprint_tasks.added_at ASC").
+		Scan(&tasks).Error
+	return tasks, err
+}
+
+func GetSprints(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	type SprintWithCounts struct {
+		models.Sprint
+		TotalTasks     int64   `json:"total_tasks"`
+		CompletedTasks int64   `json:"completed_tasks"`
+		TotalPoints    float64 `json:"total_points"`
+	}
+
+	query := db.DB.Table("sprints").
+		Select(`sprints.*,
+			COUNT(t.id) AS total_tasks,
+			COUNT(t.id) FILTER (WHERE t.status = 'completed') AS completed_tasks,
+			COALESCE(SUM(t.story_points), 0) AS total_points`).
+		Joins("LEFT JOIN sprint_tasks st ON st.sprint_id = sprints.id AND st.removed_at IS NULL AND st.deleted_at IS NULL").
+		Joins("LEFT JOIN tasks t ON t.id = st.task_id AND t.deleted_at IS NULL").
+		Where("sprints.workspace_id = ? AND sprints.deleted_at IS NULL", workspaceId).
+		Group("sprints.id")
+
+	if status := c.QueryArray("status"); len(status) > 0 {
+		query = query.Where("sprints.status IN ?", status)
+	}
+
+	var sprints []SprintWithCounts
+	if err := query.Order("sprints.start_date DESC").Scan(&sprints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sprints!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sprints": sprints})
+}
+
+func GetSprint(c *gin.Context) {
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := currentSprintTasks(sprint.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sprint tasks!"})
+		return
+	}
+
+	var completedTasks int64
+	var totalPoints, completedPoints float64
+	for _, task := range tasks {
+		points := 0.0
+		if task.StoryPoints != nil {
+			points = *task.StoryPoints
+		}
+		totalPoints += points
+		if task.Status == "completed" {
+			completedTasks++
+			completedPoints += points
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"sprint": sprint,
+			"tasks":  tasks,
+			"totals": gin.H{
+				"tasks":            len(tasks),
+				"completed_tasks":  completedTasks,
+				"points":           totalPoints,
+				"completed_points": completedPoints,
+			},
+		},
+	})
+}
+
+func CreateSprint(c *gin.Context) {
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	var body struct {
+		Name      string `json:"name"`
+		Goal      string `json:"goal"`
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+		return
+	}
+
+	startDate, ok := parseSprintDate(body.StartDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format."})
+		return
+	}
+	endDate, ok := parseSprintDate(body.EndDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format."})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before the start date"})
+		return
+	}
+
+	sprint := models.Sprint{
+		WorkspaceId: member.WorkspaceId,
+		Name:        name,
+		Goal:        body.Goal,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Status:      "planned",
+		CreatedBy:   member.UserId,
+	}
+
+	if err := db.DB.Create(&sprint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Sprint created successfully.",
+		"data":    sprint,
+	})
+}
+
+func UpdateSprint(c *gin.Context) {
+	var body struct {
+		Name      *string `json:"name"`
+		Goal      *string `json:"goal"`
+		StartDate *string `json:"start_date"`
+		EndDate   *string `json:"end_date"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completed sprints cannot be changed"})
+		return
+	}
+
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+			return
+		}
+		sprint.Name = name
+	}
+	if body.Goal != nil {
+		sprint.Goal = *body.Goal
+	}
+	if body.StartDate != nil {
+		startDate, ok := parseSprintDate(*body.StartDate)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format."})
+			return
+		}
+		sprint.StartDate = startDate
+	}
+	if body.EndDate != nil {
+		endDate, ok := parseSprintDate(*body.EndDate)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format."})
+			return
+		}
+		sprint.EndDate = endDate
+	}
+	if sprint.EndDate.Before(sprint.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before the start date"})
+		return
+	}
+
+	if err := db.DB.Save(&sprint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sprint updated successfully.",
+		"data":    sprint,
+	})
+}
+
+func DeleteSprint(c *gin.Context) {
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status == "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Complete the sprint before deleting it"})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sprint_id = ?", sprint.ID).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sprint).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sprint deleted successfully."})
+}
+
+var errSprintActive = errors.New("another sprint is active")
+
+// StartSprint activates a planned sprint and records what the team committed to.
+// Only one sprint per workspace can run at a time.
+func StartSprint(c *gin.Context) {
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status != "planned" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only planned sprints can be started"})
+		return
+	}
+
+	tasks, err := currentSprintTasks(sprint.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sprint tasks!"})
+		return
+	}
+
+	now := time.Now()
+	sprint.Status = "active"
+	sprint.StartedAt = &now
+	sprint.CommittedTasks = int64(len(tasks))
+	sprint.CommittedPoints = 0
+	for _, task := range tasks {
+		if task.StoryPoints != nil {
+			sprint.CommittedPoints += *task.StoryPoints
+		}
+	}
+
+	// The workspace row is locked so two sprints can't be started at the same time
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var workspace models.Workspace
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&workspace, sprint.WorkspaceId).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&models.Sprint{}).Where("workspace_id = ? AND status = 'active'", sprint.WorkspaceId).Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errSprintActive
+		}
+
+		return tx.Save(&sprint).Error
+	}); err != nil {
+		if errors.Is(err, errSprintActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another sprint is already active in this workspace"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sprint started.",
+		"data":    sprint,
+	})
+}
+
+// CompleteSprint closes the sprint. Unfinished tasks either move to carry_over_to
+// or go back to the backlog.
+func CompleteSprint(c *gin.Context) {
+	var body struct {
+		CarryOverTo *uint `json:"carry_over_to"`
+	}
+
+	// The body is optional, completing without one sends unfinished work to the backlog
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status != "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only active sprints can be completed"})
+		return
+	}
+
+	if body.CarryOverTo != nil {
+		var target models.Sprint
+		if err := db.DB.Where("id = ? AND workspace_id = ? AND status = 'planned'", *body.CarryOverTo, sprint.WorkspaceId).First(&target).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unfinished tasks can only be carried over to a planned sprint of this workspace"})
+			return
+		}
+	}
+
+	tasks, err := currentSprintTasks(sprint.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sprint tasks!"})
+		return
+	}
+
+	now := time.Now()
+	completed, carried := 0, 0
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			if task.Status == "completed" {
+				completed++
+				continue
+			}
+			if err := tx.Model(&models.SprintTask{}).Where("id = ?", task.SprintTask.ID).Updates(map[string]interface{}{
+				"removed_at":      now,
+				"carried_over_to": body.CarryOverTo,
+			}).Error; err != nil {
+				return err
+			}
+			if body.CarryOverTo == nil {
+				continue
+			}
+			if err := tx.Create(&models.SprintTask{
+				SprintId:    *body.CarryOverTo,
+				TaskId:      task.TaskId,
+				AddedAt:     now,
+				CarriedFrom: &sprint.ID,
+			}).Error; err != nil {
+				return err
+			}
+			carried++
+		}
+		return tx.Model(&sprint).Updates(map[string]interface{}{
+			"status":       "completed",
+			"completed_at": now,
+		}).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Sprint completed.",
+		"completed":    completed,
+		"unfinished":   len(tasks) - completed,
+		"carried_over": carried,
+	})
+}
+
+func AddSprintTasks(c *gin.Context) {
+	var body struct {
+		TaskIds []uint `json:"task_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completed sprints cannot be changed"})
+		return
+	}
+
+	taskIds := utils.Unique(body.TaskIds)
+	var count int64
+	db.DB.Model(&models.Task{}).Where("id IN ? AND workspace_id = ? AND type = 'task'", append(taskIds, 0), sprint.WorkspaceId).Count(&count)
+	if int(count) != len(taskIds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tasks must belong to this workspace"})
+		return
+	}
+
+	// A task can only be planned in one open sprint at a time
+	var planned []struct {
+		TaskId   uint
+		SprintId uint
+	}
+	if err := db.DB.Table("sprint_tasks").
+		Select("sprint_tasks.task_id, sprint_tasks.sprint_id").
+		Joins("JOIN sprints ON sprints.id = sprint_tasks.sprint_id AND sprints.deleted_at IS NULL").
+		Where("sprint_tasks.task_id IN ? AND sprint_tasks.removed_at IS NULL AND sprint_tasks.deleted_at IS NULL AND sprints.status <> 'completed'", append(taskIds, 0)).
+		Scan(&planned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tasks to sprint!"})
+		return
+	}
+
+	inSprint := map[uint]bool{}
+	for _, row := range planned {
+		if row.SprintId != sprint.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Some tasks are already planned in another sprint"})
+			return
+		}
+		inSprint[row.TaskId] = true
+	}
+
+	now := time.Now()
+	added := 0
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, taskId := range taskIds {
+			if inSprint[taskId] {
+				continue
+			}
+			if err := tx.Create(&models.SprintTask{SprintId: sprint.ID, TaskId: taskId, AddedAt: now}).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tasks to sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tasks added to sprint.",
+		"added":   added,
+	})
+}
+
+func RemoveSprintTask(c *gin.Context) {
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completed sprints cannot be changed"})
+		return
+	}
+
+	var sprintTask models.SprintTask
+	if err := db.DB.Where("sprint_id = ? AND task_id = ? AND removed_at IS NULL", sprint.ID, c.Param("taskId")).First(&sprintTask).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task is not part of this sprint"})
+		return
+	}
+
+	// Planned sprints have no burndown yet, so the row can simply go
+	var err error
+	if sprint.Status == "planned" {
+		err = db.DB.Delete(&sprintTask).Error
+	} else {
+		err = db.DB.Model(&sprintTask).Update("removed_at", time.Now()).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove task from sprint!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task removed from sprint."})
+}
+
+// GetSprintBurndown rebuilds scope and completed work for every day of the sprint.
+// A task counts as done on a day when its last status change up to the end of that
+// day moved it to completed; tasks without status history fall back to completed_at.
+func GetSprintBurndown(c *gin.Context) {
+	sprint, ok := findSprint(c)
+	if !ok {
+		return
+	}
+
+	unit := c.DefaultQuery("unit", "points")
+	weight, ok := sprintUnits[unit]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unit must be one of points, hours or tasks"})
+		return
+	}
+
+	type Day struct {
+		Date      string  `json:"date"`
+		Scope     float64 `json:"scope"`
+		Completed float64 `json:"completed"`
+	}
+
+	var days []Day
+	if err := db.DB.Raw(`
+		WITH days AS (
+			SELECT generate_series(@start::date, @end::date, INTERVAL '1 day')::date AS day
+		),
+		scope AS (
+			SELECT st.task_id, st.added_at, st.removed_at, t.completed_at, `+weight+` AS weight
+			FROM sprint_tasks st
+			JOIN tasks t ON t.id = st.task_id AND t.deleted_at IS NULL
+			WHERE st.sprint_id = @sprint AND st.deleted_at IS NULL
+		)
+		SELECT
+			TO_CHAR(d.day, 'YYYY-MM-DD') AS date,
+			COALESCE(SUM(s.weight), 0) AS scope,
+			COALESCE(SUM(s.weight) FILTER (WHERE s.done), 0) AS completed
+		FROM days d
+		LEFT JOIN LATERAL (
+			SELECT sc.weight,
+				COALESCE(
+					(SELECT h.after = 'completed' FROM task_histories h
+						WHERE h.task_id = sc.task_id AND h.action = 'status_update' AND h.deleted_at IS NULL
+							AND h.created_at < d.day + INTERVAL '1 day'
+						ORDER BY h.created_at DESC, h.id DESC LIMIT 1),
+					sc.completed_at < d.day + INTERVAL '1 day',
+					false
+				) AS done
+			FROM scope sc
+			WHERE sc.added_at < d.day + INTERVAL '1 day'
+				AND (sc.removed_at IS NULL OR sc.removed_at >= d.day + INTERVAL '1 day')
+		) s ON true
+		GROUP BY d.day
+		ORDER BY d.day
+	`, map[string]interface{}{
+		"start":  sprint.StartDate.Format(time.DateOnly),
+		"end":    sprint.EndDate.Format(time.DateOnly),
+		"sprint": sprint.ID,
+	}).Scan(&days).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build burndown!"})
+		return
+	}
+
+	type Point struct {
+		Date      string   `json:"date"`
+		Scope     *float64 `json:"scope"`
+		Completed *float64 `json:"completed"`
+		Remaining *float64 `json:"remaining"`
+		Ideal     float64  `json:"ideal"`
+	}
+
+	// The ideal line runs from the scope on the first day down to zero on the last
+	start := 0.0
+	if len(days) > 0 {
+		start = days[0].Scope
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	series := make([]Point, 0, len(days))
+	for i, day := range days {
+		ideal := start
+		if len(days) > 1 {
+			ideal = start * float64(len(days)-1-i) / float64(len(days)-1)
+		}
+		point := Point{Date: day.Date, Ideal: ideal}
+		// Future days stay empty so charts stop at today
+		if day.Date <= today {
+			remaining := day.Scope - day.Completed
+			point.Scope = &day.Scope
+			point.Completed = &day.Completed
+			point.Remaining = &remaining
+		}
+		series = append(series, point)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"sprint": sprint,
+			"unit":   unit,
+			"series": series,
+		},
+	})
+}
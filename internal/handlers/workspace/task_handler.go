@@ -0,0 +1,489 @@
+package workspace
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/events"
+	"master-management-api/internal/handlers/project"
+	"master-management-api/internal/models"
+	"master-management-api/internal/notifications"
+	"master-management-api/internal/permissions"
+	"master-management-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// validateUsers de-duplicates the user IDs and makes sure everyone belongs to the workspace.
+func validateUsers(c *gin.Context, workspaceId uint, userIds []uint, message string) ([]uint, bool) {
+	userIds = utils.Unique(userIds)
+
+	ok, err := permissions.AreMembers(workspaceId, userIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate members!"})
+		return nil, false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return nil, false
+	}
+
+	return userIds, true
+}
+
+func validateAssignees(c *gin.Context, workspaceId uint, assignees []uint) ([]uint, bool) {
+	return validateUsers(c, workspaceId, assignees, "Assignees must be members of the workspace")
+}
+
+// visibleTasks limits a task query to what the member may see. Guests only get the
+// tasks that were shared with them.
+func visibleTasks(c *gin.Context, query *gorm.DB) *gorm.DB {
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	if permissions.Can(member.Role, permissions.ViewAllTasks) {
+		return query
+	}
+	return query.Where("id IN (?)", permissions.GuestTaskIds(member.WorkspaceId, member.UserId))
+}
+
+func findWorkspaceTask(c *gin.Context) (models.Task, bool) {
+	var task models.Task
+	if err := visibleTasks(c, db.DB.Where("id = ? AND workspace_id = ?", c.Param("taskId"), c.Param("workspaceId"))).First(&task).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return task, false
+	}
+	return task, true
+}
+
+func CreateWorkspaceTask(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	workspaceId64, err := strconv.ParseUint(c.Param("workspaceId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace id"})
+		return
+	}
+	workspaceId := uint(workspaceId64)
+
+	var body struct {
+		Title           string   `json:"title"`
+		Description     string   `json:"description"`
+		Status          string   `json:"status"`
+		Type            string   `json:"type"`
+		ParentId        *uint    `json:"parent_id"`
+		Priority        *string  `json:"priority"`
+		Category        *string  `json:"category"`
+		Tags            []string `json:"tags"`
+		Assignees       []uint   `json:"assignees"`
+		TeamId          uint     `json:"team_id"`
+		ProjectId       uint     `json:"project_id"`
+		TargetValue     *float64 `json:"target_value"`
+		TargetType      *string  `json:"target_type"`
+		TargetFrequency *string  `json:"target_frequency"`
+		EstimatedHours  *float64 `json:"estimated_hours"`
+		StoryPoints     *float64 `json:"story_points"`
+		DueDate         *string  `json:"due_date"`
+		ReminderOffsets *[]int   `json:"reminder_offsets"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	body.Title = strings.TrimSpace(body.Title)
+	if body.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required!"})
+		return
+	}
+	if body.Type == "" {
+		body.Type = "task"
+	}
+	if body.EstimatedHours != nil && *body.EstimatedHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estimated hours cannot be negative"})
+		return
+	}
+	if body.StoryPoints != nil && *body.StoryPoints < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Story points cannot be negative"})
+		return
+	}
+	if body.ReminderOffsets != nil && !notifications.ValidOffsets(*body.ReminderOffsets) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Up to 5 reminders can be set, each at most 30 days before the due date"})
+		return
+	}
+	if body.Type != "task" && body.Type != "goal" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be either 'task' or 'goal'!"})
+		return
+	}
+	if body.Status == "" {
+		body.Status = "todo"
+	}
+
+	if body.ParentId != nil {
+		var parent models.Task
+		if err := db.DB.Where("id = ? AND workspace_id = ?", *body.ParentId, workspaceId).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent task not found in this workspace"})
+			return
+		}
+	}
+
+	assignees, ok := validateAssignees(c, workspaceId, body.Assignees)
+	if !ok {
+		return
+	}
+
+	teamId, ok := validateTeam(c, workspaceId, body.TeamId)
+	if !ok {
+		return
+	}
+
+	projectId, ok := project.Validate(c, body.ProjectId, &workspaceId, userId)
+	if !ok {
+		return
+	}
+
+	task := models.Task{
+		UserId:          userId,
+		Title:           body.Title,
+		Description:     body.Description,
+		Status:          body.Status,
+		Type:            body.Type,
+		ParentId:        body.ParentId,
+		Priority:        body.Priority,
+		Category:        body.Category,
+		WorkspaceId:     &workspaceId,
+		Assignees:       &assignees,
+		TeamId:          teamId,
+		ProjectId:       projectId,
+		TargetValue:     body.TargetValue,
+		TargetType:      body.TargetType,
+		TargetFrequency: body.TargetFrequency,
+		EstimatedHours:  body.EstimatedHours,
+		StoryPoints:     body.StoryPoints,
+		ReminderOffsets: body.ReminderOffsets,
+	}
+	if body.Tags != nil {
+		task.Tags = &body.Tags
+	}
+	if body.DueDate != nil && *body.DueDate != "" {
+		parsedDate, err := time.Parse(time.DateOnly, *body.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format."})
+			return
+		}
+		task.DueDate = &parsedDate
+	}
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskCreated{Task: task, ActorId: userId})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		return
+	}
+	outbox.Flush()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task Created successfully.",
+		"data":    task,
+	})
+}
+
+func GetWorkspaceTask(c *gin.Context) {
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	var checklists []models.Checklist
+	if err := db.DB.Where("task_id = ?", task.ID).Order("id ASC").Find(&checklists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist"})
+		return
+	}
+
+	var subtasks []models.Task
+	if err := db.DB.Where("parent_id = ?", task.ID).Order("id ASC").Find(&subtasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subtasks"})
+		return
+	}
+
+	type Assignee struct {
+		UserId       uint   `json:"user_id"`
+		Name         string `json:"name"`
+		AvatarUrl    string `json:"avatar_url"`
+		ProfileColor string `json:"profile_color"`
+	}
+
+	assignees := []Assignee{}
+	if task.Assignees != nil && len(*task.Assignees) > 0 {
+		if err := db.DB.Table("members").
+			Select("members.user_id, CONCAT(users.first_name, ' ', users.last_name) as name, members.avatar_url, members.profile_color").
+			Joins("JOIN users ON users.id = members.user_id").
+			Where("members.workspace_id = ? AND members.user_id IN ? AND members.deleted_at IS NULL", task.WorkspaceId, *task.Assignees).
+			Scan(&assignees).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve assignees"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"task":       task,
+			"assignees":  assignees,
+			"checklists": checklists,
+			"subtasks":   subtasks,
+		},
+	})
+}
+
+func UpdateWorkspaceTask(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	var body struct {
+		Title           *string   `json:"title"`
+		Description     *string   `json:"description"`
+		Status          *string   `json:"status"`
+		Priority        *string   `json:"priority"`
+		Category        *string   `json:"category"`
+		Tags            *[]string `json:"tags"`
+		Assignees       *[]uint   `json:"assignees"`
+		TeamId          *uint     `json:"team_id"`    // 0 removes the team
+		ProjectId       *uint     `json:"project_id"` // 0 removes the project
+		TargetValue     *float64  `json:"target_value"`
+		TargetType      *string   `json:"target_type"`
+		TargetFrequency *string   `json:"target_frequency"`
+		TargetProgress  *float64  `json:"target_progress"`
+		EstimatedHours  *float64  `json:"estimated_hours"`
+		StoryPoints     *float64  `json:"story_points"`
+		DueDate         *string   `json:"due_date"`
+		ReminderOffsets *[]int    `json:"reminder_offsets"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	before := task
+	var assignees []uint
+	if body.Assignees != nil {
+		assignees, ok = validateAssignees(c, *task.WorkspaceId, *body.Assignees)
+		if !ok {
+			return
+		}
+	}
+
+	if body.Title != nil {
+		title := strings.TrimSpace(*body.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required!"})
+			return
+		}
+		task.Title = title
+	}
+	if body.Description != nil {
+		task.Description = *body.Description
+	}
+	if body.Status != nil {
+		if *body.Status == "completed" && task.Status != "completed" {
+			currentTime := time.Now()
+			task.CompletedAt = &currentTime
+		}
+		task.Status = *body.Status
+	}
+	if body.Priority != nil {
+		task.Priority = body.Priority
+	}
+	if body.Category != nil {
+		if *body.Category == "" {
+			task.Category = nil
+		} else {
+			task.Category = body.Category
+		}
+	}
+	if body.Tags != nil {
+		task.Tags = body.Tags
+	}
+	if body.Assignees != nil {
+		task.Assignees = &assignees
+	}
+	if body.TeamId != nil {
+		teamId, ok := validateTeam(c, *task.WorkspaceId, *body.TeamId)
+		if !ok {
+			return
+		}
+		task.TeamId = teamId
+	}
+	if body.ProjectId != nil && (task.ProjectId == nil || *task.ProjectId != *body.ProjectId) {
+		projectId, ok := project.Validate(c, *body.ProjectId, task.WorkspaceId, userId)
+		if !ok {
+			return
+		}
+		task.ProjectId = projectId
+	}
+	if body.TargetValue != nil {
+		task.TargetValue = body.TargetValue
+	}
+	if body.TargetType != nil {
+		task.TargetType = body.TargetType
+	}
+	if body.TargetFrequency != nil {
+		task.TargetFrequency = body.TargetFrequency
+	}
+	if body.TargetProgress != nil {
+		task.TargetProgress = body.TargetProgress
+	}
+	if body.EstimatedHours != nil {
+		if *body.EstimatedHours < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estimated hours cannot be negative"})
+			return
+		}
+		// Zero clears the estimate
+		if *body.EstimatedHours == 0 {
+			task.EstimatedHours = nil
+		} else {
+			task.EstimatedHours = body.EstimatedHours
+		}
+	}
+	if body.StoryPoints != nil {
+		if *body.StoryPoints < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Story points cannot be negative"})
+			return
+		}
+		if *body.StoryPoints == 0 {
+			task.StoryPoints = nil
+		} else {
+			task.StoryPoints = body.StoryPoints
+		}
+	}
+	if body.DueDate != nil {
+		if *body.DueDate == "" {
+			task.DueDate = nil
+		} else {
+			parsedDue, err := time.Parse(time.DateOnly, *body.DueDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date format."})
+				return
+			}
+			task.DueDate = &parsedDue
+		}
+	}
+	if body.ReminderOffsets != nil {
+		if !notifications.ValidOffsets(*body.ReminderOffsets) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Up to 5 reminders can be set, each at most 30 days before the due date"})
+			return
+		}
+		task.ReminderOffsets = body.ReminderOffsets
+	}
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskUpdated{Before: before, After: task, ActorId: userId})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		return
+	}
+	outbox.Flush()
+
+	response := gin.H{
+		"message": "Task updated successfully",
+		"data":    task,
+	}
+
+	if body.Status != nil && task.ParentId != nil {
+		progress, err := utils.Progress(*task.ParentId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
+			return
+		}
+		response["parent_progress"] = progress
+	}
+	if body.TargetProgress != nil || body.TargetValue != nil {
+		progress, err := utils.Progress(task.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
+			return
+		}
+		response["progress"] = progress
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func DeleteWorkspaceTask(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	// Members may delete what they created, anything else needs the delete capability
+	if task.UserId != userId && !permissions.Can(member.Role, permissions.DeleteTasks) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&task).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, events.TaskDeleted{Task: task, ActorId: userId})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		return
+	}
+	outbox.Flush()
+
+	if task.ParentId != nil {
+		progress, err := utils.Progress(*task.ParentId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate progress!"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Sub Task deleted successfully",
+			"parent_progress": progress,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+}
+
+func GetWorkspaceTaskHistory(c *gin.Context) {
+	task, ok := findWorkspaceTask(c)
+	if !ok {
+		return
+	}
+
+	var entries []models.TaskHistory
+	if err := db.DB.Where("task_id = ?", task.ID).Order("created_at ASC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
@@ -0,0 +1,362 @@
+package workspace
+
+import (
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type teamMemberWithName struct {
+	models.TeamMember
+	Name         string `json:"name"`
+	AvatarUrl    string `json:"avatar_url"`
+	ProfileColor string `json:"profile_color"`
+}
+
+func findTeam(c *gin.Context) (models.Team, bool) {
+	var team models.Team
+	if err := db.DB.Where("id = ? AND workspace_id = ?", c.Param("teamId"), c.Param("workspaceId")).First(&team).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return team, false
+	}
+	return team, true
+}
+
+// canManageTeam lets workspace admins manage every team and leads manage their own.
+func canManageTeam(c *gin.Context, team models.Team) bool {
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	if permissions.Can(member.Role, permissions.ManageMembers) {
+		return true
+	}
+	return team.LeadId != nil && *team.LeadId == member.UserId
+}
+
+// validateTeam checks that the team belongs to the workspace. A zero ID clears the team.
+func validateTeam(c *gin.Context, workspaceId uint, teamId uint) (*uint, bool) {
+	if teamId == 0 {
+		return nil, true
+	}
+
+	var count int64
+	db.DB.Model(&models.Team{}).Where("id = ? AND workspace_id = ?", teamId, workspaceId).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Team not found in this workspace"})
+		return nil, false
+	}
+	return &teamId, true
+}
+
+// removeFromTeams drops a departing member from every team of the workspace.
+func removeFromTeams(workspaceId interface{}, userId uint) {
+	if err := db.DB.Where("user_id = ? AND team_id IN (?)", userId,
+		db.DB.Model(&models.Team{}).Select("id").Where("workspace_id = ?", workspaceId),
+	).Delete(&models.TeamMember{}).Error; err != nil {
+		log.Printf("Failed to remove user %d from teams: %v", userId, err)
+	}
+	if err := db.DB.Model(&models.Team{}).
+		Where("workspace_id = ? AND lead_id = ?", workspaceId, userId).
+		Update("lead_id", nil).Error; err != nil {
+		log.Printf("Failed to clear team lead %d: %v", userId, err)
+	}
+}
+
+func GetTeams(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	type TeamWithCounts struct {
+		models.Team
+		MemberCount int64  `json:"member_count"`
+		OpenTasks   int64  `json:"open_tasks"`
+		LeadName    string `json:"lead_name"`
+	}
+
+	var teams []TeamWithCounts
+	if err := db.DB.Table("teams").
+		Select(`teams.*,
+			(SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = teams.id AND tm.deleted_at IS NULL) AS member_count,
+			(SELECT COUNT(*) FROM tasks t WHERE t.team_id = teams.id AND t.deleted_at IS NULL AND t.status <> 'completed') AS open_tasks,
+			COALESCE(CONCAT(users.first_name, ' ', users.last_name), '') AS lead_name`).
+		Joins("LEFT JOIN users ON users.id = teams.lead_id").
+		Where("teams.workspace_id = ? AND teams.deleted_at IS NULL", workspaceId).
+		Order("teams.name ASC").
+		Scan(&teams).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve teams!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func GetTeam(c *gin.Context) {
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	var members []teamMemberWithName
+	if err := db.DB.Table("team_members").
+		Select("team_members.*, CONCAT(users.first_name, ' ', users.last_name) as name, members.avatar_url, members.profile_color").
+		Joins("JOIN users ON users.id = team_members.user_id").
+		Joins("LEFT JOIN members ON members.user_id = team_members.user_id AND members.workspace_id = ? AND members.deleted_at IS NULL", team.WorkspaceId).
+		Where("team_members.team_id = ? AND team_members.deleted_at IS NULL", team.ID).
+		Scan(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve team members!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"team":    team,
+			"members": members,
+		},
+	})
+}
+
+func CreateTeam(c *gin.Context) {
+	memberData, _ := c.Get("member")
+	member := memberData.(models.Member)
+
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Color       string `json:"color"`
+		LeadId      *uint  `json:"lead_id"`
+		Members     []uint `json:"members"` // user IDs
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+		return
+	}
+
+	userIds := body.Members
+	if body.LeadId != nil {
+		userIds = append(userIds, *body.LeadId)
+	}
+	userIds, ok := validateUsers(c, member.WorkspaceId, userIds, "Team members must be members of the workspace")
+	if !ok {
+		return
+	}
+
+	team := models.Team{
+		WorkspaceId: member.WorkspaceId,
+		Name:        name,
+		Description: body.Description,
+		Color:       body.Color,
+		LeadId:      body.LeadId,
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		for _, userId := range userIds {
+			if err := tx.Create(&models.TeamMember{TeamId: team.ID, UserId: userId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team!"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team created successfully.",
+		"data":    team,
+	})
+}
+
+func UpdateTeam(c *gin.Context) {
+	var body struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Color       *string `json:"color"`
+		LeadId      *uint   `json:"lead_id"` // 0 removes the lead
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required!"})
+			return
+		}
+		team.Name = name
+	}
+	if body.Description != nil {
+		team.Description = *body.Description
+	}
+	if body.Color != nil {
+		team.Color = *body.Color
+	}
+	if body.LeadId != nil {
+		if *body.LeadId == 0 {
+			team.LeadId = nil
+		} else {
+			if _, ok := validateUsers(c, team.WorkspaceId, []uint{*body.LeadId}, "The team lead must be a member of the workspace"); !ok {
+				return
+			}
+			team.LeadId = body.LeadId
+		}
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&team).Error; err != nil {
+			return err
+		}
+		if team.LeadId == nil {
+			return nil
+		}
+		// The lead is always part of the team
+		var count int64
+		tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", team.ID, *team.LeadId).Count(&count)
+		if count > 0 {
+			return nil
+		}
+		return tx.Create(&models.TeamMember{TeamId: team.ID, UserId: *team.LeadId}).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update team!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team updated successfully.",
+		"data":    team,
+	})
+}
+
+func DeleteTeam(c *gin.Context) {
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("team_id = ?", team.ID).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&team).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete team!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted successfully."})
+}
+
+func AddTeamMembers(c *gin.Context) {
+	var body struct {
+		UserIds []uint `json:"user_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body!"})
+		return
+	}
+
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageTeam(c, team) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+
+	userIds, ok := validateUsers(c, team.WorkspaceId, body.UserIds, "Team members must be members of the workspace")
+	if !ok {
+		return
+	}
+
+	var existing []uint
+	db.DB.Model(&models.TeamMember{}).Where("team_id = ? AND user_id IN ?", team.ID, append(userIds, 0)).Pluck("user_id", &existing)
+
+	added := 0
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, userId := range userIds {
+			isMember := false
+			for _, id := range existing {
+				if id == userId {
+					isMember = true
+					break
+				}
+			}
+			if isMember {
+				continue
+			}
+			if err := tx.Create(&models.TeamMember{TeamId: team.ID, UserId: userId}).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add team members!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team members added.",
+		"added":   added,
+	})
+}
+
+func RemoveTeamMember(c *gin.Context) {
+	team, ok := findTeam(c)
+	if !ok {
+		return
+	}
+
+	if !canManageTeam(c, team) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+
+	var teamMember models.TeamMember
+	if err := db.DB.Where("team_id = ? AND user_id = ?", team.ID, c.Param("userId")).First(&teamMember).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team member not found"})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if team.LeadId != nil && *team.LeadId == teamMember.UserId {
+			if err := tx.Model(&team).Update("lead_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&teamMember).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove team member!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team member removed."})
+}
@@ -0,0 +1,174 @@
+package workspace
+
+import (
+	"master-management-api/internal/db"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxWorkloadDays = 92
+
+// workloadRange reads startDate / endDate (DD-MM-YYYY) and defaults to the current week.
+// The returned end is exclusive.
+func workloadRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	end := start.AddDate(0, 0, 7)
+
+	if value := c.Query("startDate"); value != "" {
+		parsed, err := time.ParseInLocation("02-01-2006", value, now.Location())
+		if err != nil {
+			return start, end, false
+		}
+		start = parsed
+	}
+	if value := c.Query("endDate"); value != "" {
+		parsed, err := time.ParseInLocation("02-01-2006", value, now.Location())
+		if err != nil {
+			return start, end, false
+		}
+		end = parsed.AddDate(0, 0, 1)
+	} else if c.Query("startDate") != "" {
+		end = start.AddDate(0, 0, 7)
+	}
+
+	if !end.After(start) || end.Sub(start) > maxWorkloadDays*24*time.Hour {
+		return start, end, false
+	}
+	return start, end, true
+}
+
+func GetWorkload(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+
+	start, end, ok := workloadRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range"})
+		return
+	}
+
+	type MemberWorkload struct {
+		MemberId          uint    `json:"member_id"`
+		UserId            uint    `json:"user_id"`
+		Name              string  `json:"name"`
+		Role              string  `json:"role"`
+		AvatarUrl         string  `json:"avatar_url"`
+		ProfileColor      string  `json:"profile_color"`
+		WeeklyTargetHours uint    `json:"weekly_target_hours"`
+		OpenTasks         int64   `json:"open_tasks"`
+		OverdueTasks      int64   `json:"overdue_tasks"`
+		CompletedTasks    int64   `json:"completed_tasks"`
+		EstimatedHours    float64 `json:"estimated_hours"`
+		LoggedHours       float64 `json:"logged_hours"`
+		CapacityHours     float64 `json:"capacity_hours"`
+		Utilization       float64 `json:"utilization" gorm:"-"`
+		Overloaded        bool    `json:"overloaded" gorm:"-"`
+	}
+
+	// Estimates are split evenly between assignees. A task counts towards the range
+	// when it is due inside it, or when it is still open and has no due date.
+	var workload []MemberWorkload
+	if err := db.DB.Raw(`
+		WITH workspace_tasks AS (
+			SELECT
+				t.*,
+				CASE WHEN jsonb_typeof(t.assignees::jsonb) = 'array' THEN t.assignees::jsonb ELSE '[]'::jsonb END AS assignee_list
+			FROM tasks t
+			WHERE t.workspace_id = @workspace AND t.deleted_at IS NULL AND t.assignees IS NOT NULL
+		),
+		assigned AS (
+			SELECT
+				(a.value)::bigint AS user_id,
+				t.status,
+				t.due_date,
+				t.completed_at,
+				COALESCE(t.estimated_hours, 0) / GREATEST(jsonb_array_length(t.assignee_list), 1) AS estimate
+			FROM workspace_tasks t
+			CROSS JOIN LATERAL jsonb_array_elements_text(t.assignee_list) a
+		),
+		task_stats AS (
+			SELECT
+				user_id,
+				COUNT(*) FILTER (WHERE status <> 'completed') AS open_tasks,
+				COUNT(*) FILTER (WHERE status <> 'completed' AND due_date < NOW()) AS overdue_tasks,
+				COUNT(*) FILTER (WHERE status = 'completed' AND completed_at >= @start AND completed_at < @end) AS completed_tasks,
+				COALESCE(SUM(estimate) FILTER (
+					WHERE (due_date >= @start AND due_date < @end)
+						OR (due_date IS NULL AND status <> 'completed')
+				), 0) AS estimated_hours
+			FROM assigned
+			GROUP BY user_id
+		),
+		session_stats AS (
+			SELECT s.user_id, COALESCE(SUM(s.duration), 0) / 3600.0 AS logged_hours
+			FROM task_sessions s
+			JOIN tasks t ON t.id = s.task_id
+			WHERE t.workspace_id = @workspace
+				AND s.deleted_at IS NULL
+				AND s.start_time >= @start AND s.start_time < @end
+			GROUP BY s.user_id
+		)
+		SELECT
+			m.id AS member_id,
+			m.user_id,
+			CONCAT(u.first_name, ' ', u.last_name) AS name,
+			m.role,
+			m.avatar_url,
+			m.profile_color,
+			COALESCE(us.weekly_target_hours, 0) AS weekly_target_hours,
+			COALESCE(ts.open_tasks, 0) AS open_tasks,
+			COALESCE(ts.overdue_tasks, 0) AS overdue_tasks,
+			COALESCE(ts.completed_tasks, 0) AS completed_tasks,
+			COALESCE(ts.estimated_hours, 0) AS estimated_hours,
+			COALESCE(ss.logged_hours, 0) AS logged_hours,
+			COALESCE(us.weekly_target_hours, 0) * @days / 7.0 AS capacity_hours
+		FROM members m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN user_settings us ON us.user_id = m.user_id AND us.deleted_at IS NULL
+		LEFT JOIN task_stats ts ON ts.user_id = m.user_id
+		LEFT JOIN session_stats ss ON ss.user_id = m.user_id
+		WHERE m.workspace_id = @workspace AND m.deleted_at IS NULL
+	`, map[string]interface{}{
+		"workspace": workspaceId,
+		"start":     start,
+		"end":       end,
+		"days":      end.Sub(start).Hours() / 24,
+	}).Scan(&workload).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate workload!"})
+		return
+	}
+
+	for i := range workload {
+		if workload[i].CapacityHours > 0 {
+			workload[i].Utilization = workload[i].EstimatedHours / workload[i].CapacityHours
+			workload[i].Overloaded = workload[i].Utilization > 1
+		} else {
+			// Without a weekly target any estimated work counts as over capacity
+			workload[i].Overloaded = workload[i].EstimatedHours > 0
+		}
+	}
+
+	sort.SliceStable(workload, func(i, j int) bool {
+		return workload[i].Utilization > workload[j].Utilization
+	})
+
+	var unassigned int64
+	db.DB.Raw(`
+		SELECT COUNT(*) FROM tasks
+		WHERE workspace_id = ? AND deleted_at IS NULL AND status <> 'completed'
+			AND (assignees IS NULL OR assignees = 'null' OR assignees::jsonb = '[]'::jsonb)
+	`, workspaceId).Scan(&unassigned)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"start_date":       start.Format(time.DateOnly),
+			"end_date":         end.AddDate(0, 0, -1).Format(time.DateOnly),
+			"members":          workload,
+			"unassigned_tasks": unassigned,
+		},
+	})
+}
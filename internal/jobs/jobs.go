@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Payload is the typed input of a job. Its kind is stored with the job and picks the
+// handler, so it must stay stable once jobs have been queued.
+type Payload interface {
+	JobKind() string
+}
+
+type handler func(payload []byte) error
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]handler{}
+)
+
+// Register sets fn as the handler for jobs of type T. Handlers are registered once at
+// startup; a kind can only have one.
+func Register[T Payload](fn func(T) error) {
+	var zero T
+	kind := zero.JobKind()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := handlers[kind]; exists {
+		panic(fmt.Sprintf("jobs: handler for %q registered twice", kind))
+	}
+	handlers[kind] = func(payload []byte) error {
+		var job T
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return err
+		}
+		return fn(job)
+	}
+}
+
+func lookup(kind string) (handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	fn, ok := handlers[kind]
+	return fn, ok
+}
+
+func newJob(job Payload, runAt time.Time, maxAttempts int) (models.Job, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return models.Job{
+		Kind:        job.JobKind(),
+		Payload:     string(payload),
+		Status:      "queued",
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	}, nil
+}
+
+// Enqueue queues a job to run as soon as a worker is free. Passing a transaction makes
+// the job part of it, so it only runs if the transaction commits.
+func Enqueue(tx *gorm.DB, job Payload) error {
+	return EnqueueAt(tx, job, time.Now())
+}
+
+// EnqueueAt queues a job that does not run before runAt.
+func EnqueueAt(tx *gorm.DB, job Payload, runAt time.Time) error {
+	row, err := newJob(job, runAt, defaultMaxAttempts)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&row).Error
+}
+
+// enqueueOnce queues a scheduled run unless another scheduler already queued it.
+func enqueueOnce(job Payload, runAt time.Time) error {
+	row, err := newJob(job, runAt, 1)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s@%s", row.Kind, runAt.UTC().Format(time.RFC3339))
+	row.UniqueKey = &key
+	return db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+}
+
+// Purge removes finished jobs: successful runs after a day, failures after a month.
+func Purge() error {
+	now := time.Now()
+	return db.DB.Unscoped().
+		Where("(status = 'succeeded' AND finished_at < ?) OR (status = 'failed' AND finished_at < ?)",
+			now.Add(-succeededRetention), now.Add(-failedRetention)).
+		Delete(&models.Job{}).Error
+}
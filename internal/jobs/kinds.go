@@ -0,0 +1,35 @@
+package jobs
+
+// ExportData builds a user's data export archive.
+type ExportData struct {
+	ExportId uint `json:"export_id"`
+}
+
+// SendEmail sends one email, retrying while the mail server is unavailable.
+type SendEmail struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Recurring maintenance work queued by the scheduler.
+type (
+	SendReminders   struct{}
+	GenerateDigests struct{}
+	DeliverWebhooks struct{}
+	DispatchEvents  struct{}
+	PurgeWorkspaces struct{}
+	PurgeJobs       struct{}
+	PurgeRateLimits struct{}
+)
+
+func (ExportData) JobKind() string      { return "export.data" }
+func (SendEmail) JobKind() string       { return "email.send" }
+func (SendReminders) JobKind() string   { return "reminders.send" }
+func (GenerateDigests) JobKind() string { return "digests.generate" }
+func (DeliverWebhooks) JobKind() string { return "webhooks.deliver" }
+func (DispatchEvents) JobKind() string  { return "events.dispatch" }
+func (PurgeWorkspaces) JobKind() string { return "workspaces.purge" }
+func (PurgeJobs) JobKind() string       { return "jobs.purge" }
+func (PurgeRateLimits) JobKind() string { return "ratelimits.purge" }
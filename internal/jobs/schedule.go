@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type schedule interface {
+	// next returns the first run strictly after t.
+	next(t time.Time) time.Time
+}
+
+// interval runs on multiples of its duration, so every scheduler agrees on the slots.
+type interval time.Duration
+
+func (i interval) next(t time.Time) time.Time {
+	d := time.Duration(i)
+	return t.Truncate(d).Add(d)
+}
+
+// cronSpec is a standard five field cron expression: minute, hour, day of month,
+// month and day of week, evaluated in the server's time zone.
+type cronSpec struct {
+	minute, hour, day, month, weekday map[int]bool
+	// Like cron, when both day fields are restricted either one matching is enough
+	anyDay, anyWeekday bool
+}
+
+func parseField(field string, low int, high int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, value, found := strings.Cut(part, "/"); found {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part, step = base, parsed
+		}
+
+		start, end := low, high
+		if part != "*" {
+			from, to, isRange := strings.Cut(part, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			}
+		}
+		if start < low || end > high || start > end {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, low, high)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func parseCron(spec string) (cronSpec, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return cronSpec{}, fmt.Errorf("cron expression %q must have 5 fields", spec)
+	}
+
+	var c cronSpec
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return c, err
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return c, err
+	}
+	if c.day, err = parseField(fields[2], 1, 31); err != nil {
+		return c, err
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return c, err
+	}
+	if c.weekday, err = parseField(fields[4], 0, 7); err != nil {
+		return c, err
+	}
+	// Sunday can be written as 0 or 7
+	if c.weekday[7] {
+		c.weekday[0] = true
+	}
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+	return c, nil
+}
+
+func (c cronSpec) matchesDay(t time.Time) bool {
+	day, weekday := c.day[t.Day()], c.weekday[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func (c cronSpec) next(t time.Time) time.Time {
+	candidate := t.Truncate(time.Minute).Add(time.Minute)
+	// Any valid expression matches within a few years, e.g. "0 0 29 2 *"
+	limit := candidate.AddDate(5, 0, 0)
+	for candidate.Before(limit) {
+		if !c.month[int(candidate.Month())] || !c.matchesDay(candidate) {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day()+1, 0, 0, 0, 0, candidate.Location())
+			continue
+		}
+		if !c.hour[candidate.Hour()] {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day(), candidate.Hour()+1, 0, 0, 0, candidate.Location())
+			continue
+		}
+		if c.minute[candidate.Minute()] {
+			return candidate
+		}
+		candidate = candidate.Add(time.Minute)
+	}
+	return limit
+}
+
+type recurring struct {
+	schedule schedule
+	job      Payload
+}
+
+var recurringJobs []recurring
+
+// Every queues job on every multiple of d, e.g. every 5 minutes on the clock.
+func Every(d time.Duration, job Payload) {
+	recurringJobs = append(recurringJobs, recurring{schedule: interval(d), job: job})
+}
+
+// Cron queues job whenever the cron expression matches. An invalid expression is a
+// programming error and panics at startup.
+func Cron(spec string, job Payload) {
+	parsed, err := parseCron(spec)
+	if err != nil {
+		panic("jobs: " + err.Error())
+	}
+	recurringJobs = append(recurringJobs, recurring{schedule: parsed, job: job})
+}
+
+// RunScheduler queues recurring jobs when they are due. Several schedulers can run at
+// once, each run is only queued once. Scheduled runs are not retried since the next
+// one is never far away. It never returns.
+func RunScheduler() {
+	next := make([]time.Time, len(recurringJobs))
+	now := time.Now()
+	for i, r := range recurringJobs {
+		next[i] = r.schedule.next(now)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for i, r := range recurringJobs {
+			if now.Before(next[i]) {
+				continue
+			}
+			if err := enqueueOnce(r.job, next[i]); err != nil {
+				log.Printf("Failed to schedule %s: %v", r.job.JobKind(), err)
+			}
+			next[i] = r.schedule.next(now)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultMaxAttempts = 5
+	baseBackoff        = 30 * time.Second
+	maxBackoff         = time.Hour
+	// A running job is handed to another worker when its lease runs out, e.g. because
+	// the process running it was stopped.
+	lease        = 10 * time.Minute
+	pollInterval = time.Second
+
+	succeededRetention = 24 * time.Hour
+	failedRetention    = 30 * 24 * time.Hour
+)
+
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// claim takes the next due job and marks it running. It returns nil when nothing is due.
+func claim() (*models.Job, error) {
+	var job models.Job
+	now := time.Now()
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// A job whose worker died on its last attempt is not run again
+		if err := tx.Model(&models.Job{}).
+			Where("status = 'running' AND locked_until < ? AND attempts >= max_attempts", now).
+			Updates(map[string]interface{}{
+				"status":       "failed",
+				"finished_at":  now,
+				"locked_until": nil,
+				"last_error":   "lease expired on the last attempt",
+			}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = 'queued' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)", now, now).
+			Order("run_at ASC").
+			Limit(1).
+			Find(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		until := now.Add(lease)
+		job.Status = "running"
+		job.Attempts++
+		job.LockedUntil = &until
+		job.StartedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_until": until,
+			"started_at":   now,
+		}).Error
+	})
+	if err != nil || job.ID == 0 {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func run(job models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn, ok := lookup(job.Kind)
+	if !ok {
+		return errors.New("no handler registered for " + job.Kind)
+	}
+	return fn([]byte(job.Payload))
+}
+
+// finish records the outcome. The attempt check keeps a worker whose lease expired
+// from overwriting the result of the worker that took the job over.
+func finish(job models.Job, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{"locked_until": nil}
+
+	switch {
+	case err == nil:
+		updates["status"] = "succeeded"
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case job.Attempts < job.MaxAttempts:
+		updates["status"] = "queued"
+		updates["run_at"] = now.Add(backoff(job.Attempts))
+		updates["last_error"] = err.Error()
+	default:
+		updates["status"] = "failed"
+		updates["finished_at"] = now
+		updates["last_error"] = err.Error()
+		log.Printf("Job %d (%s) failed after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+	}
+
+	if err := db.DB.Model(&models.Job{}).Where("id = ? AND attempts = ?", job.ID, job.Attempts).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record outcome of job %d: %v", job.ID, err)
+	}
+}
+
+func work() {
+	for {
+		job, err := claim()
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job == nil {
+			time.Sleep(pollInterval)
+			continue
+		}
+		finish(*job, run(*job))
+	}
+}
+
+// RunWorker processes jobs with the given number of goroutines. It never returns.
+func RunWorker(concurrency int) {
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
+}
@@ -27,6 +27,11 @@ func getAllowedOrigins() map[string]bool {
 	return origins
 }
 
+// AllowedOrigin reports whether browsers on the origin may call the API.
+func AllowedOrigin(origin string) bool {
+	return getAllowedOrigins()[origin]
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	allowedOrigins := getAllowedOrigins()
 	return func(c *gin.Context) {
@@ -35,7 +40,8 @@ func CORSMiddleware() gin.HandlerFunc {
 		if allowedOrigins[origin] {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-CSRF-Token")
+			c.Writer.Header().Set("Access-Control-Expose-Headers", "X-CSRF-Token, Retry-After")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		}
 
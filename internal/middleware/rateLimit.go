@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Limiter is a token bucket store. Take consumes one token from the bucket
+// identified by key and reports how long to wait when the bucket is empty.
+type Limiter interface {
+	Take(key string, perSecond float64, burst int) (bool, time.Duration, error)
+}
+
+var (
+	limiter     Limiter
+	limiterOnce sync.Once
+)
+
+// getLimiter picks the backend from RATE_LIMIT_BACKEND ("memory" or "postgres").
+func getLimiter() Limiter {
+	limiterOnce.Do(func() {
+		if strings.ToLower(os.Getenv("RATE_LIMIT_BACKEND")) == "postgres" {
+			limiter = &postgresLimiter{}
+		} else {
+			limiter = &memoryLimiter{buckets: map[string]*memoryBucket{}}
+		}
+	})
+	return limiter
+}
+
+func refill(tokens float64, last time.Time, now time.Time, perSecond float64, burst int) float64 {
+	tokens += now.Sub(last).Seconds() * perSecond
+	return math.Min(tokens, float64(burst))
+}
+
+func waitFor(tokens float64, perSecond float64) time.Duration {
+	return time.Duration((1 - tokens) / perSecond * float64(time.Second))
+}
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	calls   int
+}
+
+func (l *memoryLimiter) Take(key string, perSecond float64, burst int) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	// Periodically drop buckets that have refilled completely
+	l.calls++
+	if l.calls%1000 == 0 {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > time.Hour {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = refill(bucket.tokens, bucket.last, now, perSecond, burst)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, waitFor(bucket.tokens, perSecond), nil
+	}
+
+	bucket.tokens--
+	return true, 0, nil
+}
+
+type postgresLimiter struct{}
+
+func (l *postgresLimiter) Take(key string, perSecond float64, burst int) (bool, time.Duration, error) {
+	allowed := false
+	var retryAfter time.Duration
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		initial := models.RateLimitBucket{Key: key, Tokens: float64(burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&initial).Error; err != nil {
+			return err
+		}
+
+		var bucket models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&bucket).Error; err != nil {
+			return err
+		}
+
+		bucket.Tokens = refill(bucket.Tokens, bucket.UpdatedAt, now, perSecond, burst)
+		bucket.UpdatedAt = now
+
+		if bucket.Tokens < 1 {
+			retryAfter = waitFor(bucket.Tokens, perSecond)
+		} else {
+			bucket.Tokens--
+			allowed = true
+		}
+
+		return tx.Save(&bucket).Error
+	})
+
+	return allowed, retryAfter, err
+}
+
+// PurgeRateLimitBuckets deletes Postgres buckets that have not been touched for an
+// hour. They have refilled completely by then, so dropping them changes nothing.
+func PurgeRateLimitBuckets() error {
+	return db.DB.Where("updated_at < ?", time.Now().Add(-time.Hour)).Delete(&models.RateLimitBucket{}).Error
+}
+
+// TooManyRequests aborts with 429 and a Retry-After header rounded up to whole seconds.
+func TooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       message,
+		"retry_after": seconds,
+	})
+}
+
+// RateLimit allows perMinute requests per key with bursts of up to burst requests.
+// Requests with an empty key are not limited.
+func RateLimit(name string, perMinute float64, burst int, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	perSecond := perMinute / 60
+
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter, err := getLimiter().Take(name+":"+key, perSecond, burst)
+		if err != nil {
+			// Fail open rather than locking everyone out when the store is unavailable
+			log.Printf("Rate limiter error: %v", err)
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			TooManyRequests(c, retryAfter, "Too many requests. Please try again later.")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ClientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// AccountKey keys requests by the email in the JSON body, leaving the body readable
+// for the handler.
+func AccountKey(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	raw, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return ""
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if email == "" {
+		return ""
+	}
+
+	return "account:" + email
+}
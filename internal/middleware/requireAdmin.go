@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"master-management-api/internal/models"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAdmin reports whether the email is listed in ADMIN_EMAILS.
+func isAdmin(email string) bool {
+	for _, admin := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		admin = strings.TrimSpace(admin)
+		if admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdmin limits a route to operators of the instance. It runs after RequireAuth.
+func RequireAdmin(c *gin.Context) {
+	userData, _ := c.Get("user")
+	if user, ok := userData.(models.User); !ok || !isAdmin(user.Email) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		return
+	}
+	c.Next()
+}
@@ -14,6 +14,7 @@ import (
 
 func RequireAuth(c *gin.Context) {
 	var tokenString string
+	authSource := "header"
 
 	// 1. First try to read from Authorization header
 	authHeader := c.GetHeader("Authorization")
@@ -24,6 +25,7 @@ func RequireAuth(c *gin.Context) {
 		cookie, err := c.Cookie("Authorization")
 		if err == nil {
 			tokenString = cookie
+			authSource = "cookie"
 		}
 	}
 
@@ -69,8 +71,23 @@ func RequireAuth(c *gin.Context) {
 		return
 	}
 
+	// Make sure the session behind the token has not been revoked
+	if tokenId, ok := claims["jti"].(string); ok && tokenId != "" {
+		var session models.AuthSession
+		if err := db.DB.Where("token_id = ? AND user_id = ? AND revoked_at IS NULL", tokenId, user.ID).First(&session).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: session revoked"})
+			return
+		}
+		c.Set("tokenId", tokenId)
+	} else if user.PasswordChangedAt != nil {
+		// Tokens issued before sessions were tracked can't be revoked individually
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: token expired"})
+		return
+	}
+
 	// Attach user to request
 	c.Set("user", user)
+	c.Set("authSource", authSource)
 
 	// Continue
 	c.Next()
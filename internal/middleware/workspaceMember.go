@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireWorkspaceMember loads the caller's membership for :workspaceId and attaches
+// it to the request as "member".
+func RequireWorkspaceMember(c *gin.Context) {
+	userData, _ := c.Get("user")
+	userId := userData.(models.User).ID
+
+	member, err := permissions.GetMember(c.Param("workspaceId"), userId)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You are not a member of this workspace"})
+		return
+	}
+
+	c.Set("member", member)
+
+	c.Next()
+}
+
+// RequireCapability rejects members whose role lacks the capability.
+// It must run after RequireWorkspaceMember.
+func RequireCapability(capability permissions.Capability) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memberData, exists := c.Get("member")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You are not a member of this workspace"})
+			return
+		}
+
+		if !permissions.Can(memberData.(models.Member).Role, capability) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+			return
+		}
+
+		c.Next()
+	}
+}
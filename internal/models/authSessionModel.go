@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuthSession struct {
+	gorm.Model
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserId    uint       `json:"user_id" gorm:"index"`
+	TokenId   string     `json:"-" gorm:"uniqueIndex"` // jti claim of the issued token
+	UserAgent string     `json:"user_agent"`
+	IpAddress string     `json:"ip_address"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
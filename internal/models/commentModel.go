@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Comment struct {
+	gorm.Model
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	TaskId   uint       `json:"task_id" gorm:"index"`
+	UserId   uint       `json:"user_id"`
+	ParentId *uint      `json:"parent_id"` // nil for top level comments
+	Content  string     `json:"content"`
+	EditedAt *time.Time `json:"edited_at"`
+}
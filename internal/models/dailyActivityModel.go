@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DailyActivity is a per-user, per-day rollup of focus time and completions, kept up to
+// date by event subscribers so analytics do not have to scan every session.
+type DailyActivity struct {
+	gorm.Model
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserId         uint      `json:"user_id" gorm:"uniqueIndex:idx_daily_activity"`
+	Date           time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_daily_activity"`
+	FocusSeconds   int64     `json:"focus_seconds"`
+	Sessions       int64     `json:"sessions"`
+	TasksCompleted int64     `json:"tasks_completed"`
+}
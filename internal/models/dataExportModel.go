@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DataExport struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserId      uint       `json:"user_id" gorm:"index"`
+	Status      string     `json:"status"` // "pending" | "processing" | "completed" | "failed"
+	Archive     []byte     `json:"-"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
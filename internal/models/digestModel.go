@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Digest struct {
+	gorm.Model
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	UserId      uint          `json:"user_id" gorm:"uniqueIndex:idx_digest_period"`
+	Period      string        `json:"period" gorm:"uniqueIndex:idx_digest_period"` // "daily" | "weekly"
+	PeriodStart time.Time     `json:"period_start" gorm:"uniqueIndex:idx_digest_period"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Summary     DigestSummary `json:"summary" gorm:"serializer:json"`
+	HTML        string        `json:"-"`
+	Text        string        `json:"-"`
+	EmailedAt   *time.Time    `json:"emailed_at"`
+}
+
+type DigestSummary struct {
+	CompletedCount int64          `json:"completed_count"`
+	Completed      []DigestTask   `json:"completed"` // the most recent ones only
+	FocusSeconds   int64          `json:"focus_seconds"`
+	StreaksKept    []DigestStreak `json:"streaks_kept"`
+	StreaksBroken  []DigestStreak `json:"streaks_broken"`
+	Upcoming       []DigestTask   `json:"upcoming"`
+	Goals          []DigestGoal   `json:"goals"`
+}
+
+type DigestTask struct {
+	TaskId      uint       `json:"task_id"`
+	Title       string     `json:"title"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type DigestStreak struct {
+	TaskId uint   `json:"task_id"`
+	Title  string `json:"title"`
+	Streak uint   `json:"streak"`
+}
+
+type DigestGoal struct {
+	TaskId uint    `json:"task_id"`
+	Title  string  `json:"title"`
+	Before float64 `json:"before"` // progress in percent
+	After  float64 `json:"after"`
+}
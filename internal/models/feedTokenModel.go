@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeedToken authorises a user's private activity feed link for one workspace.
+// Rotating it replaces Token, deleting the row revokes the link.
+type FeedToken struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserId      uint       `json:"user_id" gorm:"uniqueIndex:idx_feed_token_owner"`
+	WorkspaceId uint       `json:"workspace_id" gorm:"uniqueIndex:idx_feed_token_owner"`
+	Token       string     `json:"-" gorm:"uniqueIndex"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
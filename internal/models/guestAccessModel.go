@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// GuestAccess shares a single task or a whole project with a guest member.
+// Exactly one of TaskId and ProjectId is set.
+type GuestAccess struct {
+	gorm.Model
+	ID          uint  `json:"id" gorm:"primaryKey"`
+	WorkspaceId uint  `json:"workspace_id" gorm:"index"`
+	UserId      uint  `json:"user_id" gorm:"index"`
+	TaskId      *uint `json:"task_id"`
+	ProjectId   *uint `json:"project_id"`
+	GrantedBy   uint  `json:"granted_by"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job is a unit of background work. Workers claim queued jobs whose RunAt has passed,
+// and running jobs whose lease expired because their worker stopped.
+type Job struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Kind        string     `json:"kind" gorm:"index"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status" gorm:"index"` // "queued" | "running" | "succeeded" | "failed"
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at" gorm:"index"`
+	LockedUntil *time.Time `json:"locked_until"`
+	UniqueKey   *string    `json:"unique_key" gorm:"uniqueIndex"` // stops the same scheduled run from being queued twice
+	LastError   string     `json:"last_error"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
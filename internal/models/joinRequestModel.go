@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JoinRequest struct {
+	gorm.Model
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceId  uint       `json:"workspace_id" gorm:"index"`
+	UserId       uint       `json:"user_id"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"` // "pending" | "approved" | "rejected"
+	InvitationId *uint      `json:"invitation_id"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+}
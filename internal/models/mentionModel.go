@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Mention struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CommentId   uint       `json:"comment_id" gorm:"index"`
+	TaskId      uint       `json:"task_id"`
+	WorkspaceId uint       `json:"workspace_id"`
+	UserId      uint       `json:"user_id" gorm:"index"` // the mentioned user
+	MentionedBy uint       `json:"mentioned_by"`
+	ReadAt      *time.Time `json:"read_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Notification struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserId      uint       `json:"user_id" gorm:"index"`
+	Kind        string     `json:"kind"` // "assignment" | "mention" | "due_soon" | "goal_progress" | "milestone"
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	WorkspaceId *uint      `json:"workspace_id"`
+	TaskId      *uint      `json:"task_id"`
+	ActorId     *uint      `json:"actor_id"`
+	DedupeKey   string     `json:"-" gorm:"index"` // stops repeated events, e.g. the same due date, from notifying twice
+	ReadAt      *time.Time `json:"read_at"`
+}
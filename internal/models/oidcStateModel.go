@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OIDCState struct {
+	gorm.Model
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	State        string    `json:"state" gorm:"uniqueIndex"`
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"-"`
+	Nonce        string    `json:"-"`
+	BindingHash  string    `json:"-"`       // hash of the cookie set in the browser that started the flow
+	UserId       *uint     `json:"user_id"` // set when linking an identity to a signed in user
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event waiting to be handed to its subscribers. It is written
+// in the same transaction as the change that caused it, so the side effects can never
+// be lost even if the process stops right after the commit.
+type OutboxEvent struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"index"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status" gorm:"index"` // "pending" | "processed" | "failed"
+	Attempts    int        `json:"attempts"`
+	AvailableAt time.Time  `json:"available_at" gorm:"index"`
+	Completed   *[]string  `json:"completed" gorm:"serializer:json"` // subscribers that already handled the event
+	Error       string     `json:"error"`
+	ProcessedAt *time.Time `json:"processed_at"`
+}
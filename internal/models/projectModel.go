@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Project struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceId *uint      `json:"workspace_id" gorm:"index"` // nil for personal projects
+	UserId      uint       `json:"user_id"`                   // creator
+	OwnerId     *uint      `json:"owner_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"` // "planned" | "active" | "on_hold" | "completed"
+	Color       string     `json:"color"`
+	StartDate   *time.Time `json:"start_date"`
+	DueDate     *time.Time `json:"due_date"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SentReminder records every reminder that went out so restarts and overlapping
+// scheduler runs never send the same reminder twice.
+type SentReminder struct {
+	gorm.Model
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	TaskId        uint      `json:"task_id" gorm:"uniqueIndex:idx_sent_reminder"`
+	UserId        uint      `json:"user_id" gorm:"uniqueIndex:idx_sent_reminder"`
+	DueDate       time.Time `json:"due_date" gorm:"uniqueIndex:idx_sent_reminder"`
+	OffsetMinutes int       `json:"offset_minutes" gorm:"uniqueIndex:idx_sent_reminder"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShareLink struct {
+	gorm.Model
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	TaskId       uint       `json:"task_id" gorm:"index"`
+	UserId       uint       `json:"user_id"` // creator
+	Token        string     `json:"-" gorm:"uniqueIndex"`
+	PasswordHash string     `json:"-"` // empty when the link is not password protected
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ViewCount    uint       `json:"view_count"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Sprint struct {
+	gorm.Model
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceId     uint       `json:"workspace_id" gorm:"index"`
+	Name            string     `json:"name"`
+	Goal            string     `json:"goal"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         time.Time  `json:"end_date"`
+	Status          string     `json:"status"`           // "planned" | "active" | "completed"
+	CommittedTasks  int64      `json:"committed_tasks"`  // snapshot taken when the sprint starts
+	CommittedPoints float64    `json:"committed_points"` // snapshot taken when the sprint starts
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedBy       uint       `json:"created_by"`
+}
+
+// SprintTask keeps removed rows (RemovedAt set) so burndown scope can be rebuilt for any day.
+type SprintTask struct {
+	gorm.Model
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	SprintId      uint       `json:"sprint_id" gorm:"index"`
+	TaskId        uint       `json:"task_id" gorm:"index"`
+	AddedAt       time.Time  `json:"added_at"`
+	RemovedAt     *time.Time `json:"removed_at"`
+	CarriedFrom   *uint      `json:"carried_from"`    // sprint the task was carried over from
+	CarriedOverTo *uint      `json:"carried_over_to"` // sprint the unfinished task moved to
+}
@@ -5,7 +5,7 @@ import "gorm.io/gorm"
 type TaskHistory struct {
 	gorm.Model
 	ID     uint   `json:"id" gorm:"primaryKey"`
-	Action string `json:"action"` // "status_update" | "title_update" | "desc_update" | "started" | "stopped" | "created" | "note" | "subtask" | "checklist"
+	Action string `json:"action"` // "status_update" | "title_update" | "desc_update" | "started" | "stopped" | "created" | "note" | "subtask" | "checklist" | "assignee_added" | "assignee_removed" | "comment" | "comment_edited" | "comment_deleted" | "progress_update" | "goal_progress"
 	Before string `json:"before"`
 	After  string `json:"after"`
 	TaskId uint   `json:"task_id"`
@@ -34,10 +34,15 @@ type Task struct {
 	Achievements    *[]string  `json:"achievements" gorm:"serializer:json"`
 	WorkspaceId     *uint      `json:"workspace_id"`
 	Assignees       *[]uint    `json:"assignees" gorm:"serializer:json"`
+	TeamId          *uint      `json:"team_id"`
+	ProjectId       *uint      `json:"project_id"`
 	CompletedAt     *time.Time `json:"completed_at"`
 	Progress        *float64   `json:"progress"`
 	TargetValue     *float64   `json:"target_value"`
 	TargetType      *string    `json:"target_type"`
 	TargetFrequency *string    `json:"target_frequency"`
 	TargetProgress  *float64   `json:"target_progress"`
+	EstimatedHours  *float64   `json:"estimated_hours"`
+	StoryPoints     *float64   `json:"story_points"`
+	ReminderOffsets *[]int     `json:"reminder_offsets" gorm:"serializer:json"` // overrides the owner's defaults, empty disables reminders
 }
@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+type Team struct {
+	gorm.Model
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	WorkspaceId uint   `json:"workspace_id" gorm:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	LeadId      *uint  `json:"lead_id"` // user ID of the team lead
+}
+
+type TeamMember struct {
+	gorm.Model
+	ID     uint `json:"id" gorm:"primaryKey"`
+	TeamId uint `json:"team_id" gorm:"index"`
+	UserId uint `json:"user_id"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+type UserIdentity struct {
+	gorm.Model
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	UserId   uint   `json:"user_id" gorm:"index"`
+	Provider string `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Subject  string `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Email    string `json:"email"`
+}
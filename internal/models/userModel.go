@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -18,4 +22,8 @@ type User struct {
 	Favorites  []uint  `json:"favorites" gorm:"serializer:json"`
 	AvatarUrl  *string `json:"avatar_url"`
 	Company    *string `json:"company"`
+
+	FailedLoginAttempts uint       `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+	PasswordChangedAt   *time.Time `json:"-"`
 }
@@ -37,13 +37,20 @@ type UserSettings struct {
 	GoalProgress  bool `json:"goal_progress"`
 	SessionBreaks bool `json:"session_breaks"`
 	DailySummary  bool `json:"daily_summary"`
+	WeeklySummary bool `json:"weekly_summary"`
 	Milestone     bool `json:"milestone"`
 	NewFeature    bool `json:"new_feature"`
 
+	ReminderOffsets *[]int `json:"reminder_offsets" gorm:"serializer:json"` // minutes before the due date, nil uses the defaults
+	QuietHoursStart string `json:"quiet_hours_start"`                       // "HH:MM", empty disables quiet hours
+	QuietHoursEnd   string `json:"quiet_hours_end"`                         // "HH:MM"
+	EmailReminders  bool   `json:"email_reminders" gorm:"default:true"`
+
 	CloudSync             bool   `json:"cloud_sync"`
 	KeepCompletedFor      string `json:"keep_completed_for"`
 	AnalyticDataRetention string `json:"analytic_data_retention"`
 	AutoDeleteOldData     bool   `json:"auto_delete_old_data"`
+	HideFromTeamAnalytics bool   `json:"hide_from_team_analytics"`
 
 	// UsageData    bool `json:"usage_data"`
 	// Marketing    bool `json:"marketing"`
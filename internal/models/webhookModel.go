@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook posts events to an outside URL. Workspace webhooks receive the workspace's
+// events, personal ones (WorkspaceId nil) the owner's personal tasks and sessions.
+type Webhook struct {
+	gorm.Model
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceId  *uint      `json:"workspace_id" gorm:"index"`
+	UserId       uint       `json:"user_id" gorm:"index"` // creator, or owner of a personal webhook
+	URL          string     `json:"url"`
+	Description  string     `json:"description"`
+	Secret       string     `json:"-"`
+	Events       *[]string  `json:"events" gorm:"serializer:json"`
+	Active       bool       `json:"active"`
+	FailureCount int        `json:"failure_count"` // deliveries that failed for good in a row
+	DisabledAt   *time.Time `json:"disabled_at"`   // set when too many deliveries failed
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookId      uint       `json:"webhook_id" gorm:"index"`
+	EventId        string     `json:"event_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status" gorm:"index"` // "pending" | "delivered" | "failed"
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index"`
+	ResponseStatus *int       `json:"response_status"`
+	ResponseBody   string     `json:"response_body"`
+	Error          string     `json:"error"`
+	DurationMs     int64      `json:"duration_ms"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
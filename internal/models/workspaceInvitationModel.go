@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceInvitation struct {
+	gorm.Model
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceId uint       `json:"workspace_id" gorm:"index"`
+	Kind        string     `json:"kind"`  // "email" | "link"
+	Email       string     `json:"email"` // only for email invitations
+	Role        string     `json:"role"`
+	Token       string     `json:"-" gorm:"uniqueIndex"`
+	InvitedBy   uint       `json:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	MaxUses     uint       `json:"max_uses"` // 0 means unlimited
+	UseCount    uint       `json:"use_count"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
@@ -1,13 +1,20 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Workspace struct {
 	gorm.Model
-	ID          uint   `json:"id" gorm:"primaryKey"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	ManagerId   uint   `json:"manager_id"`
-	InviteCode  string `json:"invite_code"`
-	Type        string `json:"type"`
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	Name                string     `json:"name"`
+	Description         string     `json:"description"`
+	ManagerId           uint       `json:"manager_id"`
+	InviteCode          string     `json:"invite_code"`
+	InviteCodeExpiresAt *time.Time `json:"invite_code_expires_at"`
+	RequireApproval     bool       `json:"require_approval"`
+	Type                string     `json:"type"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
 }
@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	KindAssignment   = "assignment"
+	KindMention      = "mention"
+	KindDueSoon      = "due_soon"
+	KindGoalProgress = "goal_progress"
+	KindMilestone    = "milestone"
+)
+
+// Event is a single notification for a single user.
+type Event struct {
+	UserId      uint
+	Kind        string
+	Title       string
+	Body        string
+	WorkspaceId *uint
+	TaskId      *uint
+	ActorId     *uint
+	DedupeKey   string
+}
+
+// enabled maps every kind onto the toggle in UserSettings that controls it. Users
+// without settings get everything.
+func enabled(userId uint, kind string) bool {
+	var settings models.UserSettings
+	if err := db.DB.Where("user_id = ?", userId).First(&settings).Error; err != nil {
+		return errors.Is(err, gorm.ErrRecordNotFound)
+	}
+
+	switch kind {
+	case KindAssignment, KindMention, KindDueSoon:
+		return settings.TaskReminder
+	case KindGoalProgress:
+		return settings.GoalProgress
+	case KindMilestone:
+		return settings.Milestone
+	default:
+		return true
+	}
+}
+
+// EmailEnabled reports whether the user wants notifications by email as well.
+func EmailEnabled(userId uint) bool {
+	var settings models.UserSettings
+	if err := db.DB.Where("user_id = ?", userId).First(&settings).Error; err != nil {
+		return errors.Is(err, gorm.ErrRecordNotFound)
+	}
+	return settings.EmailReminders
+}
+
+// Publish stores the notification unless the user turned that kind off, the actor
+// is notifying themselves or an event with the same DedupeKey was already sent.
+// It reports whether the notification was stored.
+func Publish(event Event) bool {
+	if event.ActorId != nil && *event.ActorId == event.UserId {
+		return false
+	}
+	if !enabled(event.UserId, event.Kind) {
+		return false
+	}
+
+	if event.DedupeKey != "" {
+		var count int64
+		db.DB.Unscoped().Model(&models.Notification{}).Where("user_id = ? AND dedupe_key = ?", event.UserId, event.DedupeKey).Count(&count)
+		if count > 0 {
+			return false
+		}
+	}
+
+	notification := models.Notification{
+		UserId:      event.UserId,
+		Kind:        event.Kind,
+		Title:       event.Title,
+		Body:        event.Body,
+		WorkspaceId: event.WorkspaceId,
+		TaskId:      event.TaskId,
+		ActorId:     event.ActorId,
+		DedupeKey:   event.DedupeKey,
+	}
+	if err := db.DB.Create(&notification).Error; err != nil {
+		log.Printf("Failed to store %s notification for user %d: %v", event.Kind, event.UserId, err)
+		return false
+	}
+	return true
+}
+
+func actorName(actorId uint) string {
+	var actor models.User
+	if err := db.DB.Select("first_name, last_name").First(&actor, actorId).Error; err != nil {
+		return "Someone"
+	}
+	if name := strings.TrimSpace(actor.FirstName + " " + actor.LastName); name != "" {
+		return name
+	}
+	return "Someone"
+}
+
+// Assigned notifies everyone in after that was not in before.
+func Assigned(task models.Task, before []uint, after []uint, actorId uint) {
+	previous := map[uint]bool{}
+	for _, id := range before {
+		previous[id] = true
+	}
+
+	name := ""
+	for _, userId := range after {
+		if previous[userId] || userId == actorId {
+			continue
+		}
+		if name == "" {
+			name = actorName(actorId)
+		}
+		Publish(Event{
+			UserId:      userId,
+			Kind:        KindAssignment,
+			Title:       fmt.Sprintf("%s assigned you to \"%s\"", name, task.Title),
+			WorkspaceId: task.WorkspaceId,
+			TaskId:      &task.ID,
+			ActorId:     &actorId,
+		})
+	}
+}
+
+// Mentioned notifies a user that they were mentioned in a comment and reports
+// whether the notification was delivered.
+func Mentioned(task models.Task, userId uint, actorId uint, excerpt string) bool {
+	return Publish(Event{
+		UserId:      userId,
+		Kind:        KindMention,
+		Title:       fmt.Sprintf("%s mentioned you on \"%s\"", actorName(actorId), task.Title),
+		Body:        excerpt,
+		WorkspaceId: task.WorkspaceId,
+		TaskId:      &task.ID,
+		ActorId:     &actorId,
+	})
+}
+
+var milestones = []float64{25, 50, 75, 100}
+
+// GoalProgressed notifies the goal owner about every milestone crossed between
+// before and after. Reaching 100% counts as a milestone, the steps on the way as progress.
+func GoalProgressed(goal models.Task, before float64, after float64) {
+	if goal.Type != "goal" || after <= before {
+		return
+	}
+
+	for _, milestone := range milestones {
+		if before >= milestone || after < milestone {
+			continue
+		}
+
+		event := Event{
+			UserId:      goal.UserId,
+			Kind:        KindGoalProgress,
+			Title:       fmt.Sprintf("\"%s\" is %.0f%% done", goal.Title, milestone),
+			WorkspaceId: goal.WorkspaceId,
+			TaskId:      &goal.ID,
+			DedupeKey:   fmt.Sprintf("goal:%d:%.0f", goal.ID, milestone),
+		}
+		if milestone == 100 {
+			event.Kind = KindMilestone
+			event.Title = fmt.Sprintf("You reached your goal \"%s\"", goal.Title)
+		}
+		Publish(event)
+	}
+}
@@ -0,0 +1,225 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"master-management-api/internal/utils"
+	"master-management-api/pkg/mailer"
+	"sort"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Due dates carry no time of day, so reminders treat 09:00 in the user's
+	// timezone as the deadline.
+	dueTimeOfDay = 9 * time.Hour
+	maxOffset    = 30 * 24 * 60 // minutes
+	maxOffsets   = 5
+	// Reminders that could not go out (quiet hours, downtime) are dropped once the
+	// deadline is this far behind.
+	reminderGrace = 24 * time.Hour
+)
+
+// defaultOffsets remind a day ahead and on the morning of the due date.
+var defaultOffsets = []int{24 * 60, 0}
+
+// ValidOffsets checks reminder offsets given in minutes before the due date.
+func ValidOffsets(offsets []int) bool {
+	if len(offsets) > maxOffsets {
+		return false
+	}
+	for _, offset := range offsets {
+		if offset < 0 || offset > maxOffset {
+			return false
+		}
+	}
+	return true
+}
+
+func parseClock(value string) (int, bool) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
+
+// ValidQuietHours accepts two "HH:MM" values, or two empty strings to turn quiet hours off.
+func ValidQuietHours(start string, end string) bool {
+	if start == "" && end == "" {
+		return true
+	}
+	_, startOk := parseClock(start)
+	_, endOk := parseClock(end)
+	return startOk && endOk && start != end
+}
+
+type recipient struct {
+	settings models.UserSettings
+	location *time.Location
+	email    string
+}
+
+// loadRecipient reads what the scheduler needs to know about a user once per run.
+func loadRecipient(cache map[uint]*recipient, userId uint) *recipient {
+	if r, ok := cache[userId]; ok {
+		return r
+	}
+
+	var user models.User
+	if err := db.DB.Select("id, email, time_zone").First(&user, userId).Error; err != nil {
+		cache[userId] = nil
+		return nil
+	}
+
+	r := &recipient{
+		// Users without settings get the defaults
+		settings: models.UserSettings{TaskReminder: true, EmailReminders: true},
+		location: utils.Location(user.TimeZone),
+		email:    user.Email,
+	}
+	db.DB.Where("user_id = ?", userId).First(&r.settings)
+
+	cache[userId] = r
+	return r
+}
+
+func (r *recipient) inQuietHours(now time.Time) bool {
+	start, startOk := parseClock(r.settings.QuietHoursStart)
+	end, endOk := parseClock(r.settings.QuietHoursEnd)
+	if !startOk || !endOk {
+		return false
+	}
+
+	local := now.In(r.location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	// Quiet hours that run past midnight
+	return minute >= start || minute < end
+}
+
+// claim records the offsets as sent and reports whether the first one was not sent before.
+func claim(taskId uint, userId uint, dueDate time.Time, offsets []int) (bool, error) {
+	first := false
+	for i, offset := range offsets {
+		result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SentReminder{
+			TaskId:        taskId,
+			UserId:        userId,
+			DueDate:       dueDate,
+			OffsetMinutes: offset,
+		})
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if i == 0 {
+			first = result.RowsAffected > 0
+		}
+	}
+	return first, nil
+}
+
+func describeDue(deadline time.Time, now time.Time) string {
+	today := now.In(deadline.Location()).Format(time.DateOnly)
+	switch deadline.Format(time.DateOnly) {
+	case today:
+		return "today"
+	case now.In(deadline.Location()).AddDate(0, 0, 1).Format(time.DateOnly):
+		return "tomorrow"
+	default:
+		return "on " + deadline.Format("Mon, 2 Jan")
+	}
+}
+
+func deliver(task models.Task, userId uint, r *recipient, deadline time.Time, now time.Time) {
+	title := fmt.Sprintf("\"%s\" is due %s", task.Title, describeDue(deadline, now))
+
+	Publish(Event{
+		UserId:      userId,
+		Kind:        KindDueSoon,
+		Title:       title,
+		WorkspaceId: task.WorkspaceId,
+		TaskId:      &task.ID,
+	})
+
+	if !r.settings.EmailReminders || r.email == "" {
+		return
+	}
+	if err := mailer.Send(mailer.Message{
+		To:      r.email,
+		Subject: title,
+		Text:    fmt.Sprintf("Reminder: %s.\n\nYou can change when reminders are sent in your notification settings.\n", title),
+	}); err != nil {
+		log.Printf("Failed to send reminder email for task %d: %v", task.ID, err)
+	}
+}
+
+// SendDueReminders delivers every reminder that is due. The owner of a personal task
+// and the assignees of a workspace task are reminded, each at their own offsets and
+// in their own timezone. When several offsets are due at once, for example after
+// downtime, only the one closest to the deadline is sent.
+func SendDueReminders() error {
+	now := time.Now()
+
+	var tasks []models.Task
+	if err := db.DB.Where("status <> 'completed' AND due_date >= ? AND due_date < ?",
+		now.Add(-reminderGrace-48*time.Hour), now.Add(maxOffset*time.Minute+48*time.Hour),
+	).Find(&tasks).Error; err != nil {
+		return err
+	}
+
+	cache := map[uint]*recipient{}
+	for _, task := range tasks {
+		recipients := []uint{task.UserId}
+		if task.WorkspaceId != nil && task.Assignees != nil && len(*task.Assignees) > 0 {
+			recipients = *task.Assignees
+		}
+
+		due := task.DueDate.UTC()
+		for _, userId := range recipients {
+			r := loadRecipient(cache, userId)
+			if r == nil || !r.settings.TaskReminder || r.inQuietHours(now) {
+				continue
+			}
+
+			deadline := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, r.location).Add(dueTimeOfDay)
+			if now.After(deadline.Add(reminderGrace)) {
+				continue
+			}
+
+			offsets := defaultOffsets
+			if task.ReminderOffsets != nil {
+				offsets = *task.ReminderOffsets
+			} else if r.settings.ReminderOffsets != nil {
+				offsets = *r.settings.ReminderOffsets
+			}
+
+			pending := []int{}
+			for _, offset := range offsets {
+				if !now.Before(deadline.Add(-time.Duration(offset) * time.Minute)) {
+					pending = append(pending, offset)
+				}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			sort.Ints(pending)
+
+			fresh, err := claim(task.ID, userId, due, pending)
+			if err != nil {
+				log.Printf("Failed to record reminder for task %d: %v", task.ID, err)
+				continue
+			}
+			if fresh {
+				deliver(task, userId, r, deadline, now)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package permissions
+
+import (
+	"fmt"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+	RoleMember  = "member"
+	RoleViewer  = "viewer"
+	RoleGuest   = "guest"
+)
+
+type Capability string
+
+const (
+	ViewWorkspace     Capability = "view_workspace"
+	ViewMembers       Capability = "view_members"
+	ViewTasks         Capability = "view_tasks"
+	ViewAllTasks      Capability = "view_all_tasks" // without it only tasks shared through GuestAccess are visible
+	CreateTasks       Capability = "create_tasks"
+	EditTasks         Capability = "edit_tasks"
+	DeleteTasks       Capability = "delete_tasks"
+	CommentOnTasks    Capability = "comment_on_tasks"
+	ManageSprints     Capability = "manage_sprints"
+	ManageWebhooks    Capability = "manage_webhooks"
+	ManageMembers     Capability = "manage_members"
+	ViewWorkload      Capability = "view_workload"
+	ViewTeamAnalytics Capability = "view_team_analytics"
+	ChangeRoles       Capability = "change_roles"
+	ManageInvites     Capability = "manage_invites"
+	UpdateWorkspace   Capability = "update_workspace"
+	DeleteWorkspace   Capability = "delete_workspace"
+	TransferOwnership Capability = "transfer_ownership"
+)
+
+var matrix = map[string][]Capability{
+	RoleManager: {
+		ViewWorkspace, ViewMembers, ViewTasks, ViewAllTasks, CreateTasks, EditTasks, DeleteTasks, CommentOnTasks, ManageSprints,
+		ManageMembers, ViewWorkload, ViewTeamAnalytics, ChangeRoles, ManageInvites, ManageWebhooks, UpdateWorkspace, DeleteWorkspace, TransferOwnership,
+	},
+	RoleAdmin: {
+		ViewWorkspace, ViewMembers, ViewTasks, ViewAllTasks, CreateTasks, EditTasks, DeleteTasks, CommentOnTasks, ManageSprints,
+		ManageMembers, ViewWorkload, ViewTeamAnalytics, ChangeRoles, ManageInvites, ManageWebhooks, UpdateWorkspace,
+	},
+	RoleMember: {
+		ViewWorkspace, ViewMembers, ViewTasks, ViewAllTasks, CreateTasks, EditTasks, CommentOnTasks,
+	},
+	RoleViewer: {
+		ViewWorkspace, ViewMembers, ViewTasks, ViewAllTasks, CommentOnTasks,
+	},
+	RoleGuest: {
+		ViewWorkspace, ViewTasks, CommentOnTasks,
+	},
+}
+
+// rank orders roles so nobody can grant or revoke a role above their own.
+var rank = map[string]int{
+	RoleManager: 5,
+	RoleAdmin:   4,
+	RoleMember:  3,
+	RoleViewer:  2,
+	RoleGuest:   1,
+}
+
+func ValidRole(role string) bool {
+	_, ok := matrix[role]
+	return ok
+}
+
+func Can(role string, capability Capability) bool {
+	for _, c := range matrix[role] {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks reports whether role a may act on a member with role b, which takes a
+// strictly higher role. The manager is the exception and may act on anyone.
+func Outranks(a string, b string) bool {
+	return a == RoleManager || rank[a] > rank[b]
+}
+
+// CanGrant reports whether role a may hand out role b, which is any role up to its own.
+func CanGrant(a string, b string) bool {
+	return rank[a] >= rank[b]
+}
+
+// GetMember loads the active membership of a user in a workspace.
+func GetMember(workspaceId interface{}, userId uint) (models.Member, error) {
+	var member models.Member
+	err := db.DB.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&member).Error
+	return member, err
+}
+
+// AreMembers reports whether every user is an active member of the workspace.
+func AreMembers(workspaceId interface{}, userIds []uint) (bool, error) {
+	if len(userIds) == 0 {
+		return true, nil
+	}
+
+	var count int64
+	err := db.DB.Model(&models.Member{}).
+		Where("workspace_id = ? AND user_id IN ?", workspaceId, userIds).
+		Distinct("user_id").
+		Count(&count).Error
+	return count == int64(len(userIds)), err
+}
+
+func guestGrants(workspaceId interface{}, userId uint, column string) *gorm.DB {
+	return db.DB.Model(&models.GuestAccess{}).Select(column).
+		Where("workspace_id = ? AND user_id = ? AND "+column+" IS NOT NULL", workspaceId, userId)
+}
+
+// GuestProjectIds selects the projects shared with a guest.
+func GuestProjectIds(workspaceId interface{}, userId uint) *gorm.DB {
+	return guestGrants(workspaceId, userId, "project_id")
+}
+
+// GuestTaskIds selects the tasks a guest can see: tasks shared with them and their
+// subtasks, every task of a shared project, and tasks they are assigned to.
+func GuestTaskIds(workspaceId interface{}, userId uint) *gorm.DB {
+	return db.DB.Model(&models.Task{}).Select("id").
+		Where("workspace_id = ?", workspaceId).
+		Where(db.DB.Where("id IN (?)", guestGrants(workspaceId, userId, "task_id")).
+			Or("parent_id IN (?)", guestGrants(workspaceId, userId, "task_id")).
+			Or("project_id IN (?)", GuestProjectIds(workspaceId, userId)).
+			Or("assignees::jsonb @> ?::jsonb", fmt.Sprintf("[%d]", userId)))
+}
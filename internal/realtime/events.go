@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"master-management-api/internal/models"
+	"master-management-api/internal/permissions"
+)
+
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDeleted = "task.deleted"
+
+	ChecklistCreated = "checklist.created"
+	ChecklistUpdated = "checklist.updated"
+	ChecklistDeleted = "checklist.deleted"
+
+	NoteCreated = "note.created"
+	NoteUpdated = "note.updated"
+	NoteDeleted = "note.deleted"
+
+	MemberJoined  = "member.joined"
+	MemberUpdated = "member.updated"
+	MemberRemoved = "member.removed"
+
+	TimerStarted = "timer.started"
+	TimerStopped = "timer.stopped"
+)
+
+// taskTopic is where changes to a task and everything on it are published: the
+// workspace for workspace tasks, the owner for personal ones.
+func taskTopic(task models.Task) string {
+	if task.WorkspaceId != nil {
+		return WorkspaceTopic(*task.WorkspaceId)
+	}
+	return UserTopic(task.UserId)
+}
+
+// PublishTask announces a change to a task, or to something attached to it that the
+// whole task audience may see, which is passed as data.
+func PublishTask(eventType string, task models.Task, data any, actorId uint) {
+	Publish(taskTopic(task), Event{
+		Type:        eventType,
+		WorkspaceId: task.WorkspaceId,
+		TaskId:      &task.ID,
+		ActorId:     actorId,
+		Data:        data,
+	})
+}
+
+// PublishPrivate announces a change to something only its owner can see, such as
+// checklist items and notes, which are kept per user even on workspace tasks.
+func PublishPrivate(eventType string, userId uint, taskId uint, data any, actorId uint) {
+	Publish(UserTopic(userId), Event{
+		Type:    eventType,
+		TaskId:  &taskId,
+		ActorId: actorId,
+		Data:    data,
+	})
+}
+
+// PublishMember announces membership changes to the workspace. The affected user is
+// told directly too, since members who were removed or made guests stop receiving
+// workspace events.
+func PublishMember(eventType string, member models.Member, actorId uint) {
+	event := Event{
+		Type:        eventType,
+		WorkspaceId: &member.WorkspaceId,
+		ActorId:     actorId,
+		Data:        member,
+	}
+	Publish(WorkspaceTopic(member.WorkspaceId), event)
+	Publish(UserTopic(member.UserId), event)
+
+	if eventType == MemberRemoved || !permissions.Can(member.Role, permissions.ViewAllTasks) {
+		Drop(member.UserId, WorkspaceTopic(member.WorkspaceId))
+	}
+}
+
+// PublishTimer tells the user's other devices that a timer started or stopped.
+func PublishTimer(eventType string, userId uint, taskId uint) {
+	Publish(UserTopic(userId), Event{
+		Type:    eventType,
+		TaskId:  &taskId,
+		ActorId: userId,
+	})
+}
@@ -0,0 +1,121 @@
+package realtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// bufferSize is how many events a connection may fall behind before it is dropped.
+// Clients reconnect and refetch, which is cheaper than holding events for them.
+const bufferSize = 64
+
+// Event is what connected clients receive. Type is "<entity>.<change>", for example
+// "task.updated" or "timer.started".
+type Event struct {
+	Type        string    `json:"type"`
+	WorkspaceId *uint     `json:"workspace_id,omitempty"`
+	TaskId      *uint     `json:"task_id,omitempty"`
+	ActorId     uint      `json:"actor_id,omitempty"`
+	Data        any       `json:"data,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Subscriber is one open SSE or WebSocket connection. Events is closed when the
+// subscriber is removed from the hub.
+type Subscriber struct {
+	UserId uint
+	Events chan Event
+	topics map[string]bool
+	closed bool
+}
+
+type hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscriber]bool
+}
+
+var bus = &hub{topics: map[string]map[*Subscriber]bool{}}
+
+func UserTopic(userId uint) string {
+	return fmt.Sprintf("user:%d", userId)
+}
+
+func WorkspaceTopic(workspaceId uint) string {
+	return fmt.Sprintf("workspace:%d", workspaceId)
+}
+
+func Subscribe(userId uint, topics []string) *Subscriber {
+	s := &Subscriber{
+		UserId: userId,
+		Events: make(chan Event, bufferSize),
+		topics: map[string]bool{},
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for _, topic := range topics {
+		if bus.topics[topic] == nil {
+			bus.topics[topic] = map[*Subscriber]bool{}
+		}
+		bus.topics[topic][s] = true
+		s.topics[topic] = true
+	}
+	return s
+}
+
+func (h *hub) remove(s *Subscriber, topic string) {
+	delete(h.topics[topic], s)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+	delete(s.topics, topic)
+}
+
+func Unsubscribe(s *Subscriber) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if s.closed {
+		return
+	}
+	for topic := range s.topics {
+		bus.remove(s, topic)
+	}
+	s.closed = true
+	close(s.Events)
+}
+
+// Drop stops delivering a topic to the user's open connections, for example after
+// they left or were removed from a workspace.
+func Drop(userId uint, topic string) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for s := range bus.topics[topic] {
+		if s.UserId == userId {
+			bus.remove(s, topic)
+		}
+	}
+}
+
+// Publish delivers the event to everyone subscribed to the topic. It never blocks:
+// connections that fell too far behind are closed.
+func Publish(topic string, event Event) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	var slow []*Subscriber
+	bus.mu.RLock()
+	for s := range bus.topics[topic] {
+		select {
+		case s.Events <- event:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	bus.mu.RUnlock()
+
+	for _, s := range slow {
+		Unsubscribe(s)
+	}
+}
@@ -2,19 +2,31 @@ package routes
 
 import (
 	"fmt"
+	"log"
+	"master-management-api/internal/handlers/activity"
+	"master-management-api/internal/handlers/admin"
 	"master-management-api/internal/handlers/analytics"
 	"master-management-api/internal/handlers/auth"
 	"master-management-api/internal/handlers/checklist"
+	"master-management-api/internal/handlers/digest"
+	"master-management-api/internal/handlers/export"
 	"master-management-api/internal/handlers/history"
 	"master-management-api/internal/handlers/note"
+	"master-management-api/internal/handlers/notification"
 	"master-management-api/internal/handlers/profile"
+	"master-management-api/internal/handlers/project"
+	"master-management-api/internal/handlers/realtime"
 	"master-management-api/internal/handlers/settings"
+	"master-management-api/internal/handlers/share"
 	"master-management-api/internal/handlers/subtasks"
 	"master-management-api/internal/handlers/task"
+	"master-management-api/internal/handlers/webhook"
 	"master-management-api/internal/handlers/workspace"
 	"master-management-api/internal/middleware"
+	"master-management-api/internal/permissions"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,9 +35,27 @@ func handleNoRoute(c *gin.Context) {
 	c.JSON(http.StatusForbidden, gin.H{"error": "Invalid API route or endpoint"})
 }
 
+// trustedProxies reads TRUSTED_PROXIES, a comma separated list of IPs or CIDRs.
+// Nothing is trusted by default.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 func SetupRouter() {
 	router := gin.Default()
 
+	// Only trust X-Forwarded-For from the configured proxies, otherwise clients could
+	// pick their own IP and sidestep the per-IP rate limits
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	router.Use(middleware.CORSMiddleware())
 	router.NoRoute(handleNoRoute)
 
@@ -36,16 +66,42 @@ func SetupRouter() {
 		})
 	})
 
-	router.POST("/signup", auth.SignUp)
-	router.POST("/login", auth.Login)
+	router.POST("/signup",
+		middleware.RateLimit("signup", 5.0/60, 5, middleware.ClientIPKey),
+		auth.SignUp,
+	)
+	router.POST("/login",
+		middleware.RateLimit("login", 10, 10, middleware.ClientIPKey),
+		middleware.RateLimit("login", 5, 5, middleware.AccountKey),
+		auth.Login,
+	)
+	router.GET("/auth/providers", auth.GetOIDCProviders)
+	router.GET("/auth/oidc/:provider/login", middleware.RateLimit("oidc", 20, 20, middleware.ClientIPKey), auth.OIDCLogin)
+	router.GET("/auth/oidc/:provider/callback", middleware.RateLimit("oidc", 20, 20, middleware.ClientIPKey), auth.OIDCCallback)
+
+	router.GET("/exports/:exportId/download", export.DownloadExport)
+	router.GET("/feeds/workspaces/:workspaceId/activity.atom", activity.GetAtomFeed)
+	router.GET("/share/:token", middleware.RateLimit("share", 30, 30, middleware.ClientIPKey), share.GetSharedTask)
+	router.POST("/share/:token", middleware.RateLimit("share", 30, 30, middleware.ClientIPKey), share.GetSharedTask)
 
 	router.Use(middleware.RequireAuth)
+	router.Use(middleware.CSRFProtect)
 
 	router.GET("/validate", auth.Validate)
+	router.GET("/csrf-token", auth.GetCSRFToken)
 	router.POST("/logout", auth.Logout)
+	router.GET("/auth/oidc/:provider/link", auth.OIDCLink)
+	router.GET("/auth/identities", auth.GetIdentities)
+	router.DELETE("/auth/identities/:identityId", auth.UnlinkIdentity)
 
 	router.GET("/profile", profile.GetProfile)
 	router.PUT("/profile", profile.UpdateProfile)
+	router.DELETE("/profile", profile.DeleteAccount)
+	router.PUT("/profile/password", auth.ChangePassword)
+
+	router.POST("/exports", export.RequestExport)
+	router.GET("/exports", export.GetExports)
+	router.GET("/exports/:exportId", export.GetExport)
 	router.GET("/profile/monthly-stats", profile.GetMonthlyStats)
 	router.PATCH("/update-active-task", profile.UpdateActiveTask)
 	router.GET("/dashboard/quick-stats", profile.GetQuickStats)
@@ -58,6 +114,7 @@ func SetupRouter() {
 	router.GET("/recent-tasks", task.GetRecentTasks)
 	router.GET("/tasks/stats", task.GetTaskStats)
 	router.GET("/tasks/categories", task.GetCategories)
+	router.GET("/tasks/assigned", task.GetAssignedTasks)
 
 	router.GET("/tasks/:id/history", history.GetTaskHistory)
 	router.POST("/tasks/:id/history", history.AddToHistory)
@@ -69,9 +126,21 @@ func SetupRouter() {
 
 	router.POST("/tasks/:id/generate-tags", task.GenerateTags)
 
+	router.GET("/tasks/:id/share-links", share.GetShareLinks)
+	router.POST("/tasks/:id/share-links", share.CreateShareLink)
+	router.DELETE("/tasks/:id/share-links/:linkId", share.RevokeShareLink)
+
 	router.GET("/goals/stats", task.GetGoalStats)
 	router.GET("/goals/active", task.GetActiveGoals)
 
+	router.GET("/projects", project.GetProjects)
+	router.POST("/projects", project.CreateProject)
+	router.GET("/projects/:projectId", project.GetProject)
+	router.PATCH("/projects/:projectId", project.UpdateProject)
+	router.DELETE("/projects/:projectId", project.DeleteProject)
+	router.POST("/projects/:projectId/archive", project.ArchiveProject)
+	router.POST("/projects/:projectId/unarchive", project.UnarchiveProject)
+
 	router.POST("/note", note.AddNote)
 	router.GET("/notes", note.GetAllNotes)
 	router.PATCH("/notes/:noteId", note.UpdateNote)
@@ -86,15 +155,120 @@ func SetupRouter() {
 
 	router.POST("/workspace", workspace.CreateWorkspace)
 	router.GET("/workspaces", workspace.GetWorkspaces)
-	router.GET("/workspaces/:workspaceId", workspace.GetWorkspaceById)
-	router.GET("/workspaces/:workspaceId/tasks", workspace.GetWorkspaceTasks)
-	router.GET("/workspaces/:workspaceId/goals", workspace.GetWorkspaceGoals)
-	router.POST("/workspaces/:workspaceId/leave", workspace.LeaveWorkspace)
-
-	router.POST("/workspace/join", workspace.JoinWorkspace)
-	router.GET("/workspaces/:workspaceId/members", workspace.GetMembers)
-	router.DELETE("/workspaces/:workspaceId/members/:memberId", workspace.RemoveMember)
-	router.PATCH("/workspaces/:workspaceId/members/:memberId", workspace.UpdateMember)
+	router.POST("/workspace/join", middleware.RateLimit("join", 10, 10, middleware.ClientIPKey), workspace.JoinWorkspace)
+
+	router.GET("/invitations", workspace.GetMyInvitations)
+	router.GET("/invitations/:token", workspace.GetInvitation)
+	router.POST("/invitations/:token/accept", workspace.AcceptInvitation)
+
+	router.GET("/mentions", workspace.GetMyMentions)
+	router.POST("/mentions/read", workspace.MarkMentionsRead)
+
+	router.GET("/notifications", notification.GetNotifications)
+	router.GET("/notifications/unread-count", notification.GetUnreadCount)
+	router.POST("/notifications/read-all", notification.MarkAllRead)
+	router.POST("/notifications/:notificationId/read", notification.MarkRead)
+
+	router.GET("/realtime/events", realtime.Stream)
+	router.GET("/realtime/ws", realtime.Socket)
+
+	router.GET("/webhooks", webhook.GetWebhooks)
+	router.POST("/webhooks", webhook.CreateWebhook)
+	router.GET("/webhooks/events", webhook.GetWebhookEvents)
+	router.PATCH("/webhooks/:webhookId", webhook.UpdateWebhook)
+	router.DELETE("/webhooks/:webhookId", webhook.DeleteWebhook)
+	router.POST("/webhooks/:webhookId/ping", webhook.PingWebhook)
+	router.POST("/webhooks/:webhookId/rotate-secret", webhook.RotateWebhookSecret)
+	router.GET("/webhooks/:webhookId/deliveries", webhook.GetWebhookDeliveries)
+	router.POST("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", webhook.RedeliverWebhook)
+
+	router.GET("/digests", digest.GetDigests)
+	router.GET("/digests/preview", digest.PreviewDigest)
+	router.GET("/digests/:digestId", digest.GetDigest)
+
+	adminRoutes := router.Group("/admin", middleware.RequireAdmin)
+	adminRoutes.GET("/jobs", admin.GetJobs)
+	adminRoutes.GET("/jobs/stats", admin.GetJobStats)
+	adminRoutes.GET("/jobs/:jobId", admin.GetJob)
+	adminRoutes.POST("/jobs/:jobId/retry", admin.RetryJob)
+	adminRoutes.DELETE("/jobs/:jobId", admin.DeleteJob)
+
+	workspaceRoutes := router.Group("/workspaces/:workspaceId", middleware.RequireWorkspaceMember)
+	workspaceRoutes.GET("", middleware.RequireCapability(permissions.ViewWorkspace), workspace.GetWorkspaceById)
+	workspaceRoutes.PATCH("", middleware.RequireCapability(permissions.UpdateWorkspace), workspace.UpdateWorkspace)
+	workspaceRoutes.DELETE("", middleware.RequireCapability(permissions.DeleteWorkspace), workspace.DeleteWorkspace)
+	workspaceRoutes.POST("/restore", middleware.RequireCapability(permissions.DeleteWorkspace), workspace.RestoreWorkspace)
+	workspaceRoutes.POST("/transfer", middleware.RequireCapability(permissions.TransferOwnership), workspace.TransferOwnership)
+	workspaceRoutes.GET("/tasks", middleware.RequireCapability(permissions.ViewTasks), workspace.GetWorkspaceTasks)
+	workspaceRoutes.POST("/tasks", middleware.RequireCapability(permissions.CreateTasks), workspace.CreateWorkspaceTask)
+	workspaceRoutes.GET("/tasks/:taskId", middleware.RequireCapability(permissions.ViewTasks), workspace.GetWorkspaceTask)
+	workspaceRoutes.PATCH("/tasks/:taskId", middleware.RequireCapability(permissions.EditTasks), workspace.UpdateWorkspaceTask)
+	workspaceRoutes.DELETE("/tasks/:taskId", middleware.RequireCapability(permissions.EditTasks), workspace.DeleteWorkspaceTask)
+	workspaceRoutes.GET("/tasks/:taskId/history", middleware.RequireCapability(permissions.ViewTasks), workspace.GetWorkspaceTaskHistory)
+	workspaceRoutes.GET("/tasks/:taskId/comments", middleware.RequireCapability(permissions.ViewTasks), workspace.GetComments)
+	workspaceRoutes.POST("/tasks/:taskId/comments", middleware.RequireCapability(permissions.CommentOnTasks), workspace.CreateComment)
+	workspaceRoutes.PATCH("/tasks/:taskId/comments/:commentId", middleware.RequireCapability(permissions.CommentOnTasks), workspace.UpdateComment)
+	workspaceRoutes.DELETE("/tasks/:taskId/comments/:commentId", middleware.RequireCapability(permissions.CommentOnTasks), workspace.DeleteComment)
+	workspaceRoutes.GET("/goals", middleware.RequireCapability(permissions.ViewTasks), workspace.GetWorkspaceGoals)
+	workspaceRoutes.POST("/leave", workspace.LeaveWorkspace)
+
+	workspaceRoutes.GET("/projects", middleware.RequireCapability(permissions.ViewTasks), project.GetProjects)
+	workspaceRoutes.POST("/projects", middleware.RequireCapability(permissions.CreateTasks), project.CreateProject)
+	workspaceRoutes.GET("/projects/:projectId", middleware.RequireCapability(permissions.ViewTasks), project.GetProject)
+	workspaceRoutes.PATCH("/projects/:projectId", middleware.RequireCapability(permissions.EditTasks), project.UpdateProject)
+	workspaceRoutes.DELETE("/projects/:projectId", middleware.RequireCapability(permissions.DeleteTasks), project.DeleteProject)
+	workspaceRoutes.POST("/projects/:projectId/archive", middleware.RequireCapability(permissions.EditTasks), project.ArchiveProject)
+	workspaceRoutes.POST("/projects/:projectId/unarchive", middleware.RequireCapability(permissions.EditTasks), project.UnarchiveProject)
+
+	workspaceRoutes.GET("/members", middleware.RequireCapability(permissions.ViewMembers), workspace.GetMembers)
+	workspaceRoutes.GET("/guests/:userId/access", middleware.RequireCapability(permissions.ManageMembers), workspace.GetGuestAccess)
+	workspaceRoutes.POST("/guests/:userId/access", middleware.RequireCapability(permissions.ManageMembers), workspace.GrantGuestAccess)
+	workspaceRoutes.DELETE("/guests/:userId/access/:accessId", middleware.RequireCapability(permissions.ManageMembers), workspace.RevokeGuestAccess)
+	workspaceRoutes.GET("/teams", middleware.RequireCapability(permissions.ViewMembers), workspace.GetTeams)
+	workspaceRoutes.POST("/teams", middleware.RequireCapability(permissions.ManageMembers), workspace.CreateTeam)
+	workspaceRoutes.GET("/teams/:teamId", middleware.RequireCapability(permissions.ViewMembers), workspace.GetTeam)
+	workspaceRoutes.PATCH("/teams/:teamId", middleware.RequireCapability(permissions.ManageMembers), workspace.UpdateTeam)
+	workspaceRoutes.DELETE("/teams/:teamId", middleware.RequireCapability(permissions.ManageMembers), workspace.DeleteTeam)
+	workspaceRoutes.POST("/teams/:teamId/members", middleware.RequireCapability(permissions.ViewMembers), workspace.AddTeamMembers)
+	workspaceRoutes.DELETE("/teams/:teamId/members/:userId", middleware.RequireCapability(permissions.ViewMembers), workspace.RemoveTeamMember)
+	workspaceRoutes.GET("/sprints", middleware.RequireCapability(permissions.ViewAllTasks), workspace.GetSprints)
+	workspaceRoutes.POST("/sprints", middleware.RequireCapability(permissions.ManageSprints), workspace.CreateSprint)
+	workspaceRoutes.GET("/sprints/:sprintId", middleware.RequireCapability(permissions.ViewAllTasks), workspace.GetSprint)
+	workspaceRoutes.PATCH("/sprints/:sprintId", middleware.RequireCapability(permissions.ManageSprints), workspace.UpdateSprint)
+	workspaceRoutes.DELETE("/sprints/:sprintId", middleware.RequireCapability(permissions.ManageSprints), workspace.DeleteSprint)
+	workspaceRoutes.POST("/sprints/:sprintId/start", middleware.RequireCapability(permissions.ManageSprints), workspace.StartSprint)
+	workspaceRoutes.POST("/sprints/:sprintId/complete", middleware.RequireCapability(permissions.ManageSprints), workspace.CompleteSprint)
+	workspaceRoutes.POST("/sprints/:sprintId/tasks", middleware.RequireCapability(permissions.ManageSprints), workspace.AddSprintTasks)
+	workspaceRoutes.DELETE("/sprints/:sprintId/tasks/:taskId", middleware.RequireCapability(permissions.ManageSprints), workspace.RemoveSprintTask)
+	workspaceRoutes.GET("/sprints/:sprintId/burndown", middleware.RequireCapability(permissions.ViewAllTasks), workspace.GetSprintBurndown)
+	workspaceRoutes.GET("/webhooks", middleware.RequireCapability(permissions.ManageWebhooks), webhook.GetWebhooks)
+	workspaceRoutes.POST("/webhooks", middleware.RequireCapability(permissions.ManageWebhooks), webhook.CreateWebhook)
+	workspaceRoutes.PATCH("/webhooks/:webhookId", middleware.RequireCapability(permissions.ManageWebhooks), webhook.UpdateWebhook)
+	workspaceRoutes.DELETE("/webhooks/:webhookId", middleware.RequireCapability(permissions.ManageWebhooks), webhook.DeleteWebhook)
+	workspaceRoutes.POST("/webhooks/:webhookId/ping", middleware.RequireCapability(permissions.ManageWebhooks), webhook.PingWebhook)
+	workspaceRoutes.POST("/webhooks/:webhookId/rotate-secret", middleware.RequireCapability(permissions.ManageWebhooks), webhook.RotateWebhookSecret)
+	workspaceRoutes.GET("/webhooks/:webhookId/deliveries", middleware.RequireCapability(permissions.ManageWebhooks), webhook.GetWebhookDeliveries)
+	workspaceRoutes.POST("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", middleware.RequireCapability(permissions.ManageWebhooks), webhook.RedeliverWebhook)
+	workspaceRoutes.GET("/workload", middleware.RequireCapability(permissions.ViewWorkload), workspace.GetWorkload)
+	workspaceRoutes.GET("/activity", middleware.RequireCapability(permissions.ViewAllTasks), activity.GetActivity)
+	workspaceRoutes.GET("/activity/feed-url", middleware.RequireCapability(permissions.ViewAllTasks), activity.GetFeedURL)
+	workspaceRoutes.POST("/activity/feed-url/rotate", middleware.RequireCapability(permissions.ViewAllTasks), activity.RotateFeedURL)
+	workspaceRoutes.DELETE("/activity/feed-url", middleware.RequireCapability(permissions.ViewAllTasks), activity.RevokeFeedURL)
+
+	workspaceRoutes.GET("/analytics/focus-time", middleware.RequireCapability(permissions.ViewTeamAnalytics), analytics.GetTeamFocusTime)
+	workspaceRoutes.GET("/analytics/completed-per-week", middleware.RequireCapability(permissions.ViewTeamAnalytics), analytics.GetTeamCompletedPerWeek)
+	workspaceRoutes.GET("/analytics/goal-progress", middleware.RequireCapability(permissions.ViewTeamAnalytics), analytics.GetTeamGoalProgress)
+	workspaceRoutes.GET("/analytics/throughput", middleware.RequireCapability(permissions.ViewTeamAnalytics), analytics.GetTeamThroughput)
+	workspaceRoutes.DELETE("/members/:memberId", middleware.RequireCapability(permissions.ManageMembers), workspace.RemoveMember)
+	workspaceRoutes.PATCH("/members/:memberId", workspace.UpdateMember)
+
+	workspaceRoutes.POST("/invite-code/rotate", middleware.RequireCapability(permissions.ManageInvites), workspace.RotateInviteCode)
+	workspaceRoutes.POST("/invitations", middleware.RequireCapability(permissions.ManageInvites), workspace.CreateEmailInvitation)
+	workspaceRoutes.POST("/invite-links", middleware.RequireCapability(permissions.ManageInvites), workspace.CreateInviteLink)
+	workspaceRoutes.GET("/invitations", middleware.RequireCapability(permissions.ManageInvites), workspace.GetInvitations)
+	workspaceRoutes.DELETE("/invitations/:invitationId", middleware.RequireCapability(permissions.ManageInvites), workspace.RevokeInvitation)
+	workspaceRoutes.GET("/join-requests", middleware.RequireCapability(permissions.ManageInvites), workspace.GetJoinRequests)
+	workspaceRoutes.POST("/join-requests/:requestId/review", middleware.RequireCapability(permissions.ManageInvites), workspace.ReviewJoinRequest)
 
 	router.GET("/settings", settings.GetUserSettings)
 	router.PATCH("/settings", settings.UpdateUserSettings)
@@ -0,0 +1,83 @@
+package subscribers
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/events"
+	"master-management-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// bump adds delta's counters to the user's rollup for the day of at, in the database's
+// time zone like the analytics queries on task sessions.
+func bump(tx *gorm.DB, userId uint, at time.Time, delta models.DailyActivity) error {
+	return tx.Exec(`
+		INSERT INTO daily_activities (created_at, updated_at, user_id, date, focus_seconds, sessions, tasks_completed)
+		VALUES (NOW(), NOW(), ?, DATE(?), ?, ?, ?)
+		ON CONFLICT (user_id, date) DO UPDATE SET
+			updated_at = NOW(),
+			focus_seconds = daily_activities.focus_seconds + EXCLUDED.focus_seconds,
+			sessions = daily_activities.sessions + EXCLUDED.sessions,
+			tasks_completed = daily_activities.tasks_completed + EXCLUDED.tasks_completed`,
+		userId, at, delta.FocusSeconds, delta.Sessions, delta.TasksCompleted,
+	).Error
+}
+
+func registerAnalytics() {
+	events.Subscribe("analytics", func(e events.SessionStopped) error {
+		return bump(db.DB, e.UserId, e.StartTime, models.DailyActivity{FocusSeconds: e.Duration, Sessions: 1})
+	})
+
+	// A task counts once, on the day it was last completed. Moving it between days
+	// happens in one transaction so a retried event cannot count it twice.
+	events.Subscribe("analytics", func(e events.TaskUpdated) error {
+		before, after := e.Before.CompletedAt, e.After.CompletedAt
+		if after == nil || (before != nil && before.Equal(*after)) {
+			return nil
+		}
+		return db.DB.Transaction(func(tx *gorm.DB) error {
+			if before != nil {
+				if err := bump(tx, e.Before.UserId, *before, models.DailyActivity{TasksCompleted: -1}); err != nil {
+					return err
+				}
+			}
+			return bump(tx, e.After.UserId, *after, models.DailyActivity{TasksCompleted: 1})
+		})
+	})
+	events.Subscribe("analytics", func(e events.TaskDeleted) error {
+		if e.Task.CompletedAt == nil {
+			return nil
+		}
+		return bump(db.DB, e.Task.UserId, *e.Task.CompletedAt, models.DailyActivity{TasksCompleted: -1})
+	})
+}
+
+// BackfillAnalytics builds the rollups from existing sessions and completions the first
+// time the table is empty, so history from before the rollups existed is not lost.
+func BackfillAnalytics() error {
+	var count int64
+	if err := db.DB.Model(&models.DailyActivity{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+
+	statements := []string{`
+		INSERT INTO daily_activities (created_at, updated_at, user_id, date, focus_seconds, sessions)
+		SELECT NOW(), NOW(), user_id, DATE(start_time), SUM(duration), COUNT(*)
+		FROM task_sessions
+		WHERE end_time IS NOT NULL AND deleted_at IS NULL
+		GROUP BY user_id, DATE(start_time)`, `
+		INSERT INTO daily_activities (created_at, updated_at, user_id, date, tasks_completed)
+		SELECT NOW(), NOW(), user_id, DATE(completed_at), COUNT(*)
+		FROM tasks
+		WHERE completed_at IS NOT NULL AND deleted_at IS NULL
+		GROUP BY user_id, DATE(completed_at)
+		ON CONFLICT (user_id, date) DO UPDATE SET tasks_completed = EXCLUDED.tasks_completed`,
+	}
+	for _, statement := range statements {
+		if err := db.DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
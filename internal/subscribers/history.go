@@ -0,0 +1,92 @@
+package subscribers
+
+import (
+	"master-management-api/internal/events"
+	"master-management-api/internal/handlers/history"
+	"math"
+	"strconv"
+)
+
+func registerHistory() {
+	events.Subscribe("history", func(e events.TaskCreated) error {
+		task := e.Task
+		history.LogHistory("created", "", task.Title, task.ID, e.ActorId)
+		if task.ParentId != nil {
+			history.LogHistory("subtask", "", task.Title, *task.ParentId, e.ActorId)
+		}
+		if task.Assignees != nil && len(*task.Assignees) > 0 {
+			history.LogAssigneeChanges(nil, *task.Assignees, task.ID, e.ActorId)
+		}
+		return nil
+	})
+
+	events.Subscribe("history", func(e events.TaskUpdated) error {
+		logTaskChanges(e)
+		return nil
+	})
+
+	// Snapshots of the recalculated progress, which digests and the activity feed
+	// read goal progress from
+	events.Subscribe("history", func(e events.GoalProgressed) error {
+		history.LogHistory("goal_progress", formatPercent(e.Before), formatPercent(e.After), e.Task.ID, e.ActorId)
+		return nil
+	})
+}
+
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func assignees(value *[]uint) []uint {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// logTaskChanges writes a history entry for every tracked field the update changed.
+func logTaskChanges(e events.TaskUpdated) {
+	before, after := e.Before, e.After
+	record := func(action string, from string, to string) {
+		history.LogHistory(action, from, to, after.ID, e.ActorId)
+	}
+
+	if before.Title != after.Title {
+		record("title_update", before.Title, after.Title)
+	}
+	if before.Status != after.Status {
+		record("status_update", before.Status, after.Status)
+	}
+	if before.TimeSpend != after.TimeSpend {
+		record("stopped", strconv.FormatUint(uint64(before.TimeSpend), 10), strconv.FormatUint(uint64(after.TimeSpend), 10))
+	}
+	if before.Description != after.Description {
+		record("description_update", before.Description, after.Description)
+	}
+	if deref(before.Priority) != deref(after.Priority) {
+		record("priority_change", deref(before.Priority), deref(after.Priority))
+	}
+	if formatFloat(before.TargetProgress) != formatFloat(after.TargetProgress) {
+		record("progress_update", formatFloat(before.TargetProgress), formatFloat(after.TargetProgress))
+	}
+	if after.StartedAt != nil && (before.StartedAt == nil || !before.StartedAt.Equal(*after.StartedAt)) {
+		record("started", "", "")
+	}
+	if before.Assignees != nil || after.Assignees != nil {
+		history.LogAssigneeChanges(assignees(before.Assignees), assignees(after.Assignees), after.ID, e.ActorId)
+	}
+}
@@ -0,0 +1,27 @@
+package subscribers
+
+import (
+	"master-management-api/internal/events"
+	"master-management-api/internal/notifications"
+)
+
+func registerNotifications() {
+	events.Subscribe("notifications", func(e events.TaskCreated) error {
+		if e.Task.Assignees != nil {
+			notifications.Assigned(e.Task, nil, *e.Task.Assignees, e.ActorId)
+		}
+		return nil
+	})
+
+	events.Subscribe("notifications", func(e events.TaskUpdated) error {
+		if e.After.Assignees != nil {
+			notifications.Assigned(e.After, assignees(e.Before.Assignees), *e.After.Assignees, e.ActorId)
+		}
+		return nil
+	})
+
+	events.Subscribe("notifications", func(e events.GoalProgressed) error {
+		notifications.GoalProgressed(e.Task, e.Before, e.After)
+		return nil
+	})
+}
@@ -0,0 +1,52 @@
+package subscribers
+
+import (
+	"master-management-api/internal/events"
+	"master-management-api/internal/utils"
+)
+
+func recalculate(taskId uint, actorId uint) error {
+	_, err := utils.RecalculateProgress(taskId, actorId)
+	return err
+}
+
+func registerProgress() {
+	// A subtask counts towards its parent, so adding, completing or removing one
+	// moves the parent's progress
+	events.Subscribe("progress", func(e events.TaskCreated) error {
+		if e.Task.ParentId == nil {
+			return nil
+		}
+		return recalculate(*e.Task.ParentId, e.ActorId)
+	})
+
+	events.Subscribe("progress", func(e events.TaskUpdated) error {
+		if e.After.ParentId != nil && e.Before.Status != e.After.Status {
+			if err := recalculate(*e.After.ParentId, e.ActorId); err != nil {
+				return err
+			}
+		}
+		if formatFloat(e.Before.TargetProgress) != formatFloat(e.After.TargetProgress) ||
+			formatFloat(e.Before.TargetValue) != formatFloat(e.After.TargetValue) {
+			return recalculate(e.After.ID, e.ActorId)
+		}
+		return nil
+	})
+
+	events.Subscribe("progress", func(e events.TaskDeleted) error {
+		if e.Task.ParentId == nil {
+			return nil
+		}
+		return recalculate(*e.Task.ParentId, e.ActorId)
+	})
+
+	events.Subscribe("progress", func(e events.ChecklistCreated) error {
+		return recalculate(e.Checklist.TaskId, e.ActorId)
+	})
+	events.Subscribe("progress", func(e events.ChecklistToggled) error {
+		return recalculate(e.Checklist.TaskId, e.ActorId)
+	})
+	events.Subscribe("progress", func(e events.ChecklistDeleted) error {
+		return recalculate(e.Checklist.TaskId, e.ActorId)
+	})
+}
@@ -0,0 +1,73 @@
+package subscribers
+
+import (
+	"master-management-api/internal/events"
+	"master-management-api/internal/realtime"
+)
+
+func registerRealtime() {
+	events.Subscribe("realtime", func(e events.TaskCreated) error {
+		realtime.PublishTask(realtime.TaskCreated, e.Task, e.Task, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.TaskUpdated) error {
+		realtime.PublishTask(realtime.TaskUpdated, e.After, e.After, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.TaskDeleted) error {
+		realtime.PublishTask(realtime.TaskDeleted, e.Task, nil, e.ActorId)
+		return nil
+	})
+
+	events.Subscribe("realtime", func(e events.ChecklistCreated) error {
+		realtime.PublishPrivate(realtime.ChecklistCreated, e.Checklist.UserId, e.Checklist.TaskId, e.Checklist, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.ChecklistUpdated) error {
+		realtime.PublishPrivate(realtime.ChecklistUpdated, e.Checklist.UserId, e.Checklist.TaskId, e.Checklist, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.ChecklistToggled) error {
+		realtime.PublishPrivate(realtime.ChecklistUpdated, e.Checklist.UserId, e.Checklist.TaskId, e.Checklist, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.ChecklistDeleted) error {
+		realtime.PublishPrivate(realtime.ChecklistDeleted, e.Checklist.UserId, e.Checklist.TaskId, e.Checklist, e.ActorId)
+		return nil
+	})
+
+	events.Subscribe("realtime", func(e events.NoteCreated) error {
+		realtime.PublishPrivate(realtime.NoteCreated, e.Note.UserId, e.Note.TaskId, e.Note, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.NoteUpdated) error {
+		realtime.PublishPrivate(realtime.NoteUpdated, e.Note.UserId, e.Note.TaskId, e.Note, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.NoteDeleted) error {
+		realtime.PublishPrivate(realtime.NoteDeleted, e.Note.UserId, e.Note.TaskId, map[string]any{"id": e.Note.ID}, e.ActorId)
+		return nil
+	})
+
+	events.Subscribe("realtime", func(e events.SessionStarted) error {
+		realtime.PublishTimer(realtime.TimerStarted, e.UserId, e.TaskId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.SessionStopped) error {
+		realtime.PublishTimer(realtime.TimerStopped, e.UserId, e.TaskId)
+		return nil
+	})
+
+	events.Subscribe("realtime", func(e events.MemberJoined) error {
+		realtime.PublishMember(realtime.MemberJoined, e.Member, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.MemberUpdated) error {
+		realtime.PublishMember(realtime.MemberUpdated, e.Member, e.ActorId)
+		return nil
+	})
+	events.Subscribe("realtime", func(e events.MemberRemoved) error {
+		realtime.PublishMember(realtime.MemberRemoved, e.Member, e.ActorId)
+		return nil
+	})
+}
@@ -0,0 +1,20 @@
+// Package subscribers wires the side effects of domain events: task history, progress,
+// notifications, webhooks, realtime updates and analytics rollups.
+package subscribers
+
+// Register subscribes everything except realtime updates to the event bus. It is called
+// once at startup, before the server or the dispatcher handle any event.
+func Register() {
+	registerHistory()
+	registerProgress()
+	registerNotifications()
+	registerWebhooks()
+	registerAnalytics()
+}
+
+// RegisterRealtime subscribes the realtime updates. Only processes that serve HTTP call
+// it, since connected clients live there; a worker would drop the events and still
+// mark them as handled.
+func RegisterRealtime() {
+	registerRealtime()
+}
@@ -0,0 +1,57 @@
+package subscribers
+
+import (
+	"master-management-api/internal/db"
+	"master-management-api/internal/events"
+	"master-management-api/internal/models"
+	"master-management-api/internal/webhooks"
+)
+
+func registerWebhooks() {
+	events.Subscribe("webhooks", func(e events.TaskCreated) error {
+		webhooks.Dispatch(webhooks.TaskCreated, e.Task.WorkspaceId, e.Task.UserId, e.Task)
+		return nil
+	})
+
+	events.Subscribe("webhooks", func(e events.TaskUpdated) error {
+		if e.Before.Status != "completed" && e.After.Status == "completed" {
+			webhooks.Dispatch(webhooks.TaskCompleted, e.After.WorkspaceId, e.After.UserId, e.After)
+		}
+		return nil
+	})
+
+	events.Subscribe("webhooks", func(e events.GoalProgressed) error {
+		if e.Task.Type != "goal" || e.Before == e.After {
+			return nil
+		}
+		webhooks.Dispatch(webhooks.GoalProgress, e.Task.WorkspaceId, e.Task.UserId, map[string]any{
+			"task_id":  e.Task.ID,
+			"title":    e.Task.Title,
+			"before":   e.Before,
+			"progress": e.After,
+		})
+		return nil
+	})
+
+	events.Subscribe("webhooks", func(e events.SessionStopped) error {
+		var task models.Task
+		if err := db.DB.First(&task, e.TaskId).Error; err != nil {
+			return nil
+		}
+		webhooks.Dispatch(webhooks.SessionStopped, task.WorkspaceId, e.UserId, map[string]any{
+			"session_id": e.SessionId,
+			"task_id":    e.TaskId,
+			"task_title": task.Title,
+			"user_id":    e.UserId,
+			"start_time": e.StartTime,
+			"end_time":   e.EndTime,
+			"duration":   e.Duration,
+		})
+		return nil
+	})
+
+	events.Subscribe("webhooks", func(e events.MemberJoined) error {
+		webhooks.Dispatch(webhooks.MemberJoined, &e.Member.WorkspaceId, e.Member.UserId, e.Member)
+		return nil
+	})
+}
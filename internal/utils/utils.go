@@ -3,12 +3,29 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"master-management-api/internal/db"
+	"master-management-api/internal/events"
 	"master-management-api/internal/models"
 	"math"
 	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Location returns the time zone users set on their profile, UTC when unset or unknown.
+func Location(timeZone *string) *time.Location {
+	if timeZone != nil && *timeZone != "" {
+		if location, err := time.LoadLocation(*timeZone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
 func Contains[T comparable](slice []T, item T) bool {
 	for _, v := range slice {
 		if v == item {
@@ -18,6 +35,19 @@ func Contains[T comparable](slice []T, item T) bool {
 	return false
 }
 
+// Unique returns the items of slice in their original order with duplicates removed.
+func Unique[T comparable](slice []T) []T {
+	seen := make(map[T]bool, len(slice))
+	result := make([]T, 0, len(slice))
+	for _, v := range slice {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 func MatchScore(title, searchKey, desc string, useDesc bool) int {
 	if title == searchKey {
 		return 100
@@ -44,6 +74,48 @@ func GenerateInviteCode(length int) string {
 	return strings.ToUpper(code[:length])
 }
 
+// ValidatePassword enforces the password policy for new and changed passwords.
+func ValidatePassword(password string, email string) error {
+	if len(password) < 8 {
+		return errors.New("Password must be at least 8 characters long")
+	}
+	// bcrypt ignores anything past 72 bytes
+	if len(password) > 72 {
+		return errors.New("Password must be at most 72 bytes long")
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("Password must contain both letters and numbers")
+	}
+
+	if email != "" && strings.EqualFold(password, email) {
+		return errors.New("Password must not be the same as your email")
+	}
+	if local, _, found := strings.Cut(email, "@"); found && len(local) >= 4 && strings.Contains(strings.ToLower(password), strings.ToLower(local)) {
+		return errors.New("Password must not contain your email name")
+	}
+
+	return nil
+}
+
+// GenerateToken returns a URL safe random token built from n random bytes.
+func GenerateToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 func CalculateActivityProgress(goal models.Task) float64 {
 	if goal.TargetType == nil {
 		return 0
@@ -80,7 +152,20 @@ func CalculateActivityProgress(goal models.Task) float64 {
 	}
 }
 
-func RecalculateProgress(id uint) (float64, error) {
+// Progress reads a task's stored progress, e.g. after an event recalculated it.
+func Progress(id uint) (float64, error) {
+	var task models.Task
+	if err := db.DB.Select("id", "progress").First(&task, id).Error; err != nil {
+		return 0, err
+	}
+	if task.Progress == nil {
+		return 0, nil
+	}
+	return *task.Progress, nil
+}
+
+// RecalculateProgress stores the task's progress, crediting a change to actorId.
+func RecalculateProgress(id uint, actorId uint) (float64, error) {
 	var task models.Task
 	if err := db.DB.First(&task, "id = ?", id).Error; err != nil {
 		return 0, err
@@ -147,10 +232,30 @@ func RecalculateProgress(id uint) (float64, error) {
 		}
 	}
 
-	// 5️⃣ Save progress to DB
-	if err := db.DB.Model(&task).Update("progress", finalProgress).Error; err != nil {
+	// 5️⃣ Save progress to DB. The row is locked so concurrent recalculations see each
+	// other's result and the event is stored with the change.
+	var outbox events.Batch
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "progress").First(&current, id).Error; err != nil {
+			return err
+		}
+		previousProgress := 0.0
+		if current.Progress != nil {
+			previousProgress = *current.Progress
+		}
+
+		if err := tx.Model(&task).Update("progress", finalProgress).Error; err != nil {
+			return err
+		}
+		if finalProgress == previousProgress {
+			return nil
+		}
+		return outbox.Record(tx, events.GoalProgressed{Task: task, Before: previousProgress, After: finalProgress, ActorId: actorId})
+	}); err != nil {
 		return 0, err
 	}
+	outbox.Flush()
 
 	return finalProgress, nil
 }
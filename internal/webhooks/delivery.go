@@ -0,0 +1,209 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxAttempts    = 8
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+	// claimLease hides a claimed batch from other workers. Deliveries are sent one after
+	// another, so it must outlast a batch where every request times out.
+	claimLease    = batchSize*requestTimeout + time.Minute
+	responseLimit = 2048
+	// A webhook is disabled after this many deliveries in a row failed for good.
+	disableAfter = 5
+)
+
+var errBlockedAddress = errors.New("webhook URL resolves to a private or local address")
+
+// guardDial refuses connections to internal addresses, so webhooks cannot be used to
+// reach services behind the firewall. Checking at dial time also covers redirects and
+// DNS answers that change after the URL was validated.
+func guardDial(network string, address string, _ syscall.RawConn) error {
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+var client = &http.Client{
+	Timeout: requestTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: guardDial}).DialContext,
+	},
+	// Receivers must answer at the URL they registered
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Sign computes the X-Webhook-Signature header. Receivers recompute it over
+// "<X-Webhook-Timestamp>.<raw body>" with their secret and compare.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// attempt sends the delivery once and records the outcome on it.
+func attempt(delivery *models.WebhookDelivery, hook models.Webhook) bool {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "master-management-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.EventId)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(hook.Secret, timestamp, body))
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	delivery.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, responseLimit))
+	delivery.ResponseStatus = &resp.StatusCode
+	delivery.ResponseBody = string(response)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.Error = "Receiver responded with " + resp.Status
+		return false
+	}
+	return true
+}
+
+// claim picks due deliveries and pushes their next attempt back, so other workers
+// skip them while they are being sent.
+func claim() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	now := time.Now()
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = 'pending' AND next_attempt_at <= ?", now).
+			Order("next_attempt_at ASC").
+			Limit(batchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return deliveries, err
+}
+
+func deliver(delivery models.WebhookDelivery) {
+	now := time.Now()
+
+	var hook models.Webhook
+	if err := db.DB.First(&hook, delivery.WebhookId).Error; err != nil || !hook.Active || hook.DisabledAt != nil {
+		delivery.Status = "failed"
+		delivery.Error = "Webhook was deleted or disabled"
+		delivery.NextAttemptAt = nil
+		db.DB.Save(&delivery)
+		return
+	}
+
+	if attempt(&delivery, hook) {
+		delivery.Status = "delivered"
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		if hook.FailureCount > 0 {
+			db.DB.Model(&hook).Update("failure_count", 0)
+		}
+	} else if delivery.Attempts >= maxAttempts {
+		delivery.Status = "failed"
+		delivery.NextAttemptAt = nil
+
+		updates := map[string]interface{}{"failure_count": gorm.Expr("failure_count + 1")}
+		if hook.FailureCount+1 >= disableAfter {
+			updates["disabled_at"] = now
+			log.Printf("Disabled webhook %d after %d failed deliveries", hook.ID, disableAfter)
+		}
+		db.DB.Model(&hook).Updates(updates)
+	} else {
+		next := now.Add(backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := db.DB.Save(&delivery).Error; err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// DeliverDue sends every delivery whose next attempt is due.
+func DeliverDue() error {
+	for {
+		deliveries, err := claim()
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			deliver(delivery)
+		}
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"master-management-api/internal/db"
+	"master-management-api/internal/models"
+	"time"
+)
+
+const (
+	TaskCreated    = "task.created"
+	TaskCompleted  = "task.completed"
+	GoalProgress   = "goal.progress"
+	SessionStopped = "session.stopped"
+	MemberJoined   = "member.joined"
+
+	// Ping is only sent by the test endpoint and cannot be subscribed to.
+	Ping = "ping"
+)
+
+// Events lists everything a webhook can subscribe to.
+var Events = []string{TaskCreated, TaskCompleted, GoalProgress, SessionStopped, MemberJoined}
+
+func ValidEvents(events []string) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, event := range events {
+		valid := false
+		for _, known := range Events {
+			if event == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// payload is the JSON body every webhook receives.
+type payload struct {
+	Id          string    `json:"id"`
+	Event       string    `json:"event"`
+	CreatedAt   time.Time `json:"created_at"`
+	WorkspaceId *uint     `json:"workspace_id"`
+	Data        any       `json:"data"`
+}
+
+func newId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewSecret returns a signing secret for a webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func newDelivery(hook models.Webhook, event string, workspaceId *uint, data any) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	id, err := newId()
+	if err != nil {
+		return delivery, err
+	}
+	body, err := json.Marshal(payload{
+		Id:          id,
+		Event:       event,
+		CreatedAt:   time.Now().UTC(),
+		WorkspaceId: workspaceId,
+		Data:        data,
+	})
+	if err != nil {
+		return delivery, err
+	}
+
+	return models.WebhookDelivery{
+		WebhookId: hook.ID,
+		EventId:   id,
+		Event:     event,
+		Payload:   string(body),
+	}, nil
+}
+
+// enqueue stores a delivery for the worker to send.
+func enqueue(hook models.Webhook, event string, workspaceId *uint, data any) error {
+	delivery, err := newDelivery(hook, event, workspaceId, data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	delivery.Status = "pending"
+	delivery.NextAttemptAt = &now
+	return db.DB.Create(&delivery).Error
+}
+
+// Dispatch queues the event for every active webhook subscribed to it: the workspace's
+// webhooks for workspace events, the user's personal webhooks otherwise. Failures are
+// only logged so they never break the request that caused the event.
+func Dispatch(event string, workspaceId *uint, userId uint, data any) {
+	query := db.DB.Where("active = true AND disabled_at IS NULL AND events::jsonb @> ?::jsonb", fmt.Sprintf("[%q]", event))
+	if workspaceId != nil {
+		query = query.Where("workspace_id = ?", *workspaceId)
+	} else {
+		query = query.Where("workspace_id IS NULL AND user_id = ?", userId)
+	}
+
+	var hooks []models.Webhook
+	if err := query.Find(&hooks).Error; err != nil {
+		log.Printf("Failed to look up webhooks for %s: %v", event, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if err := enqueue(hook, event, workspaceId, data); err != nil {
+			log.Printf("Failed to queue %s for webhook %d: %v", event, hook.ID, err)
+		}
+	}
+}
+
+// SendPing delivers a ping right away so the caller sees the result. It is logged
+// like any other delivery but never retried.
+func SendPing(hook models.Webhook) (models.WebhookDelivery, error) {
+	delivery, err := newDelivery(hook, Ping, hook.WorkspaceId, map[string]any{
+		"webhook_id": hook.ID,
+		"message":    "Webhook is set up correctly.",
+	})
+	if err != nil {
+		return delivery, err
+	}
+
+	now := time.Now()
+	if attempt(&delivery, hook) {
+		delivery.Status = "delivered"
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.Status = "failed"
+	}
+	return delivery, db.DB.Create(&delivery).Error
+}
@@ -0,0 +1,42 @@
+// Package workers registers the handlers and schedules of every background job.
+package workers
+
+import (
+	"master-management-api/internal/digest"
+	"master-management-api/internal/events"
+	"master-management-api/internal/handlers/export"
+	"master-management-api/internal/handlers/workspace"
+	"master-management-api/internal/jobs"
+	"master-management-api/internal/middleware"
+	"master-management-api/internal/notifications"
+	"master-management-api/internal/webhooks"
+	"master-management-api/pkg/mailer"
+	"time"
+)
+
+// Register sets up job handlers and recurring schedules. It is called once at startup
+// in every mode, since the server queues jobs that workers run.
+func Register() {
+	jobs.Register(func(job jobs.ExportData) error {
+		return export.RunExport(job.ExportId)
+	})
+	jobs.Register(func(job jobs.SendEmail) error {
+		return mailer.Send(mailer.Message{To: job.To, Subject: job.Subject, Text: job.Text, HTML: job.HTML})
+	})
+
+	jobs.Register(func(jobs.SendReminders) error { return notifications.SendDueReminders() })
+	jobs.Register(func(jobs.GenerateDigests) error { return digest.GenerateDue() })
+	jobs.Register(func(jobs.DeliverWebhooks) error { return webhooks.DeliverDue() })
+	jobs.Register(func(jobs.DispatchEvents) error { return events.DispatchDue() })
+	jobs.Register(func(jobs.PurgeWorkspaces) error { return workspace.PurgeScheduledWorkspaces() })
+	jobs.Register(func(jobs.PurgeJobs) error { return jobs.Purge() })
+	jobs.Register(func(jobs.PurgeRateLimits) error { return middleware.PurgeRateLimitBuckets() })
+
+	jobs.Every(5*time.Second, jobs.DispatchEvents{})
+	jobs.Every(15*time.Second, jobs.DeliverWebhooks{})
+	jobs.Every(5*time.Minute, jobs.SendReminders{})
+	jobs.Every(15*time.Minute, jobs.GenerateDigests{})
+	jobs.Cron("0 * * * *", jobs.PurgeWorkspaces{})
+	jobs.Cron("30 3 * * *", jobs.PurgeJobs{})
+	jobs.Cron("15 * * * *", jobs.PurgeRateLimits{})
+}
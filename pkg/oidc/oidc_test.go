@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "test-client"
+	testKid      = "test-key"
+)
+
+// mockIssuer is a minimal OpenID provider serving discovery, JWKS and token
+// endpoints. The token endpoint returns whatever idToken is set to.
+type mockIssuer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	idToken  string
+	jwksHits atomic.Int32
+	lastForm map[string]string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.jwksHits.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": testKid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.lastForm = map[string]string{}
+		for k := range r.PostForm {
+			m.lastForm[k] = r.PostForm.Get(k)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockIssuer) provider() *Provider {
+	return &Provider{
+		Name:        "mock",
+		Issuer:      m.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/auth/oidc/mock/callback",
+		Scopes:      []string{"openid", "email"},
+		HTTPClient:  m.Client(),
+	}
+}
+
+func (m *mockIssuer) sign(t *testing.T, key *rsa.PrivateKey, kid string, claims Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func (m *mockIssuer) claims(nonce string) Claims {
+	return Claims{
+		Subject:       "user-123",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Nonce:         nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.URL,
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	m := newMockIssuer(t)
+	p := m.provider()
+	m.idToken = m.sign(t, m.key, testKid, m.claims("nonce-1"))
+
+	raw, err := p.Exchange("code-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if m.lastForm["code"] != "code-1" || m.lastForm["code_verifier"] != "verifier-1" {
+		t.Fatalf("unexpected token request: %v", m.lastForm)
+	}
+
+	claims, err := p.VerifyIDToken(raw, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "user@example.com" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	m := newMockIssuer(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongAudience := m.claims("nonce-1")
+	wrongAudience.Audience = jwt.ClaimStrings{"someone-else"}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"bad nonce", m.sign(t, m.key, testKid, m.claims("other-nonce"))},
+		{"bad audience", m.sign(t, m.key, testKid, wrongAudience)},
+		{"bad signature", m.sign(t, other, testKid, m.claims("nonce-1"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.provider().VerifyIDToken(tt.token, "nonce-1"); err == nil {
+				t.Fatal("expected verification to fail")
+			}
+		})
+	}
+}
+
+func TestUnknownKidRefreshIsRateLimited(t *testing.T) {
+	m := newMockIssuer(t)
+	p := m.provider()
+
+	for i := 0; i < 5; i++ {
+		raw := m.sign(t, m.key, "unknown-kid", m.claims("nonce-1"))
+		if _, err := p.VerifyIDToken(raw, "nonce-1"); err == nil {
+			t.Fatal("expected unknown kid to be rejected")
+		}
+	}
+
+	// The first lookup loads the key set; forced refreshes are suppressed
+	// because the set was fetched less than a minute ago.
+	if hits := m.jwksHits.Load(); hits != 1 {
+		t.Fatalf("expected 1 JWKS fetch, got %d", hits)
+	}
+}